@@ -0,0 +1,268 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/docherak/bd-chirpy/internal/auth"
+	"github.com/docherak/bd-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// handlerBlocksCreate blocks a user. The blocked user stops seeing the blocker's chirps, so
+// they can't reply to or quote them either, and can't follow or mention the blocker. An
+// existing follow of the blocker is removed.
+func (cfg *apiConfig) handlerBlocksCreate(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		UserID uuid.UUID `json:"user_id"`
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error getting bearer token", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	if params.UserID == userID {
+		respondWithError(w, http.StatusBadRequest, "You can't block yourself", nil)
+		return
+	}
+
+	_, err = cfg.db.GetUser(r.Context(), params.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		err := q.BlockUser(r.Context(), database.BlockUserParams{
+			UserID:    userID,
+			BlockedID: params.UserID,
+		})
+		if err != nil {
+			return err
+		}
+		return q.UnfollowUser(r.Context(), database.UnfollowUserParams{
+			FollowerID: params.UserID,
+			FolloweeID: userID,
+		})
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't block user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerBlocksDelete(w http.ResponseWriter, r *http.Request) {
+	blockedID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't parse UUID", err)
+		return
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error getting bearer token", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	err = cfg.db.UnblockUser(r.Context(), database.UnblockUserParams{
+		UserID:    userID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unblock user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerBlocksGet(w http.ResponseWriter, r *http.Request) {
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error getting bearer token", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	rows, err := cfg.db.ListBlockedUsers(r.Context(), database.ListBlockedUsersParams{
+		UserID:          userID,
+		CursorCreatedAt: page.CreatedAt,
+		CursorID:        page.ID,
+		PageSize:        int32(page.Limit + 1),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting blocked users", err)
+		return
+	}
+
+	blocks := []Follow{}
+	for _, row := range rows {
+		blocks = append(blocks, Follow{UserID: row.UserID, CreatedAt: row.CreatedAt})
+	}
+
+	respondWithJSON(w, http.StatusOK, newFollowsPage(blocks, page.Limit))
+}
+
+// handlerMutesCreate mutes a user. Muting is silent: the muted user can still see and
+// interact with the caller, their chirps just drop out of the caller's feeds.
+func (cfg *apiConfig) handlerMutesCreate(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		UserID uuid.UUID `json:"user_id"`
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error getting bearer token", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	if params.UserID == userID {
+		respondWithError(w, http.StatusBadRequest, "You can't mute yourself", nil)
+		return
+	}
+
+	_, err = cfg.db.GetUser(r.Context(), params.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+
+	err = cfg.db.MuteUser(r.Context(), database.MuteUserParams{
+		UserID:  userID,
+		MutedID: params.UserID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't mute user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerMutesDelete(w http.ResponseWriter, r *http.Request) {
+	mutedID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't parse UUID", err)
+		return
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error getting bearer token", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	err = cfg.db.UnmuteUser(r.Context(), database.UnmuteUserParams{
+		UserID:  userID,
+		MutedID: mutedID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unmute user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerMutesGet(w http.ResponseWriter, r *http.Request) {
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error getting bearer token", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	rows, err := cfg.db.ListMutedUsers(r.Context(), database.ListMutedUsersParams{
+		UserID:          userID,
+		CursorCreatedAt: page.CreatedAt,
+		CursorID:        page.ID,
+		PageSize:        int32(page.Limit + 1),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting muted users", err)
+		return
+	}
+
+	mutes := []Follow{}
+	for _, row := range rows {
+		mutes = append(mutes, Follow{UserID: row.UserID, CreatedAt: row.CreatedAt})
+	}
+
+	respondWithJSON(w, http.StatusOK, newFollowsPage(mutes, page.Limit))
+}
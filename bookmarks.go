@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/docherak/bd-chirpy/internal/auth"
+	"github.com/docherak/bd-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) handlerBookmarksCreate(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't parse UUID", err)
+		return
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error getting bearer token", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	dbChirp, err := cfg.getShareableChirp(r.Context(), userID, chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", err)
+		return
+	}
+
+	err = cfg.db.BookmarkChirp(r.Context(), database.BookmarkChirpParams{
+		UserID:  userID,
+		ChirpID: dbChirp.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't bookmark chirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerBookmarksDelete(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't parse UUID", err)
+		return
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error getting bearer token", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	// Bookmarking a rechirp saved the chirp it shares, so that is the bookmark to remove. The
+	// chirp doesn't have to be visible any more, a bookmark can always be taken back.
+	dbChirp, err := cfg.db.GetChirp(r.Context(), chirpID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirp", err)
+		return
+	}
+	if err == nil && dbChirp.RechirpOf.Valid {
+		chirpID = dbChirp.RechirpOf.UUID
+	}
+
+	err = cfg.db.UnbookmarkChirp(r.Context(), database.UnbookmarkChirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't remove bookmark", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerBookmarksGet(w http.ResponseWriter, r *http.Request) {
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error getting bearer token", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	// Deleted chirps drop out of the list but keep their bookmark, so they come back if restored.
+	rows, err := cfg.db.ListBookmarkedChirps(r.Context(), database.ListBookmarkedChirpsParams{
+		UserID:          userID,
+		CursorCreatedAt: page.CreatedAt,
+		CursorID:        page.ID,
+		PageSize:        int32(page.Limit + 1),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting bookmarks", err)
+		return
+	}
+
+	dbChirps := []database.Chirp{}
+	for _, row := range rows {
+		dbChirps = append(dbChirps, row.Chirp)
+	}
+
+	apiChirps, err := cfg.databaseChirpsToAPIChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting bookmarks", err)
+		return
+	}
+
+	// Bookmarks are paged by when they were saved, not when the chirp was posted.
+	chirps := newChirpsPage(apiChirps, page.Limit)
+	if chirps.NextCursor != "" {
+		last := rows[page.Limit-1]
+		chirps.NextCursor = encodeCursor(last.BookmarkedAt, last.Chirp.ID)
+	}
+
+	respondWithJSON(w, http.StatusOK, chirps)
+}
+
+// addBookmarks fills in whether the viewer bookmarked each chirp. Bookmarks are private, so
+// there are no counts.
+func (cfg *apiConfig) addBookmarks(ctx context.Context, viewerID uuid.NullUUID, apiChirps []Chirp) error {
+	if !viewerID.Valid {
+		return nil
+	}
+
+	bookmarkedIDs, err := cfg.db.ListBookmarkedChirpIDs(ctx, database.ListBookmarkedChirpIDsParams{
+		UserID:   viewerID.UUID,
+		ChirpIds: chirpIDs(apiChirps),
+	})
+	if err != nil {
+		return err
+	}
+	bookmarked := map[uuid.UUID]bool{}
+	for _, chirpID := range bookmarkedIDs {
+		bookmarked[chirpID] = true
+	}
+
+	for i := range apiChirps {
+		bookmarkedByMe := bookmarked[apiChirps[i].ID]
+		apiChirps[i].BookmarkedByMe = &bookmarkedByMe
+	}
+	return nil
+}
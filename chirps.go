@@ -1,25 +1,47 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
 	"net/http"
 
 	"errors"
-	"sort"
 	"strings"
 	"time"
 
 	"github.com/docherak/bd-chirpy/internal/auth"
+	"github.com/docherak/bd-chirpy/internal/chirptext"
 	"github.com/docherak/bd-chirpy/internal/database"
+	"github.com/docherak/bd-chirpy/internal/moderation"
 	"github.com/google/uuid"
 )
 
 type Chirp struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserID    uuid.UUID `json:"user_id"`
+	ID             uuid.UUID  `json:"id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	ContentWarning string     `json:"content_warning,omitempty"`
+	Body           string     `json:"body"`
+	UserID         uuid.UUID  `json:"user_id"`
+	InReplyTo      *uuid.UUID `json:"in_reply_to,omitempty"`
+	ReplyCount     int64      `json:"reply_count"`
+	LikeCount      int64      `json:"like_count"`
+	LikedByMe      *bool      `json:"liked_by_me,omitempty"`
+	BookmarkedByMe *bool      `json:"bookmarked_by_me,omitempty"`
+	IsDeleted      bool       `json:"is_deleted,omitempty"`
+	Status         string     `json:"status"`
+	Visibility     string     `json:"visibility"`
+	PublishAt      *time.Time `json:"publish_at,omitempty"`
+
+	RechirpOf    *Chirp `json:"rechirp_of,omitempty"`
+	QuoteOf      *Chirp `json:"quote_of,omitempty"`
+	RechirpCount int64  `json:"rechirp_count"`
+	QuoteCount   int64  `json:"quote_count"`
+
+	Entities    *Entities    `json:"entities,omitempty"`
+	Attachments []Attachment `json:"attachments"`
+	Poll        *Poll        `json:"poll,omitempty"`
 }
 
 func (cfg *apiConfig) handlerChirpsDeleteSingle(w http.ResponseWriter, r *http.Request) {
@@ -43,7 +65,7 @@ func (cfg *apiConfig) handlerChirpsDeleteSingle(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	dbChirp, err := cfg.db.GetChirp(r.Context(), chirpID)
+	dbChirp, err := cfg.getVisibleChirp(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirpID)
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Chirp not found", err)
 		return
@@ -54,7 +76,19 @@ func (cfg *apiConfig) handlerChirpsDeleteSingle(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	err = cfg.db.DeleteChirp(r.Context(), chirpID)
+	// Rechirps carry no content of their own, so undoing one is permanent. Everything else
+	// goes to the trash, together with the rechirps of it, until the purge worker removes it.
+	if dbChirp.RechirpOf.Valid {
+		err = cfg.db.DeleteChirp(r.Context(), chirpID)
+	} else {
+		err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+			err := q.SoftDeleteRechirpsOf(r.Context(), uuid.NullUUID{UUID: chirpID, Valid: true})
+			if err != nil {
+				return err
+			}
+			return q.SoftDeleteChirp(r.Context(), chirpID)
+		})
+	}
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to delete chirp", err)
 		return
@@ -64,87 +98,309 @@ func (cfg *apiConfig) handlerChirpsDeleteSingle(w http.ResponseWriter, r *http.R
 	return
 }
 
-func (cfg *apiConfig) handlerChirpsGetSingle(w http.ResponseWriter, r *http.Request) {
+func (cfg *apiConfig) handlerChirpsUpdate(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body      *string    `json:"body"`
+		Status    string     `json:"status"`
+		PublishAt *time.Time `json:"publish_at"`
+	}
 
-	chirpIDString := r.PathValue("chirpID")
-	chirpID, err := uuid.Parse(chirpIDString)
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "Couldn't parse UUID", err)
 		return
 	}
-	dbChirp, err := cfg.db.GetChirp(r.Context(), chirpID)
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error getting bearer token", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	dbChirp, err := cfg.getVisibleChirp(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirpID)
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Chirp not found", err)
 		return
 	}
 
-	apiChirp := databaseChirpToAPIChirp(dbChirp)
-	respondWithJSON(w, http.StatusOK, apiChirp)
-}
+	if dbChirp.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "Forbidden: You don't own this chirp", err)
+		return
+	}
 
-func (cfg *apiConfig) handlerChirpsGetAll(w http.ResponseWriter, r *http.Request) {
-	sortArg := r.URL.Query().Get("sort")
-	if sortArg == "" || (sortArg != "asc" && sortArg != "desc") {
-		sortArg = "asc"
+	if dbChirp.RechirpOf.Valid {
+		respondWithError(w, http.StatusBadRequest, "Rechirps can't be edited", nil)
+		return
 	}
 
-	authorID := r.URL.Query().Get("author_id")
-	if authorID != "" {
-		userID, err := uuid.Parse(authorID)
+	// Drafts and scheduled chirps can be edited freely and moved between draft, scheduled and
+	// published. Once a chirp is out, its status is fixed.
+	isPublished := dbChirp.Status == chirpStatusPublished
+	changeStatus := params.Status != "" || params.PublishAt != nil
+	var status string
+	var publishAt sql.NullTime
+	if changeStatus {
+		status, publishAt, err = parseChirpStatus(params.Status, params.PublishAt)
 		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "Couldn't parse UUID", err)
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		if isPublished && status != chirpStatusPublished {
+			respondWithError(w, http.StatusBadRequest, "Published chirps can't be unpublished", nil)
 			return
 		}
-		dbChirps, err := cfg.db.GetChirpsByUser(r.Context(), userID)
+		if status == chirpStatusDraft {
+			_, err := cfg.db.GetPoll(r.Context(), chirpID)
+			if err == nil {
+				respondWithError(w, http.StatusBadRequest, "Drafts can't have polls", nil)
+				return
+			}
+			if !errors.Is(err, sql.ErrNoRows) {
+				respondWithError(w, http.StatusInternalServerError, "Couldn't get poll", err)
+				return
+			}
+		}
+	}
+
+	// Chirpy Red members may edit at any time, everyone else only shortly after posting.
+	if isPublished {
+		user, err := cfg.db.GetUser(r.Context(), userID)
 		if err != nil {
-			respondWithError(w, http.StatusInternalServerError, "Error getting chirps", err)
+			respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
 			return
 		}
-		apiChirps := []Chirp{}
-		for _, dbChirp := range dbChirps {
-			apiChirps = append(apiChirps, databaseChirpToAPIChirp(dbChirp))
+		if !user.IsChirpyRed && time.Since(dbChirp.CreatedAt) > cfg.chirpEditWindow {
+			respondWithError(w, http.StatusForbidden, "Edit window has passed", nil)
+			return
+		}
+	}
+
+	// Without a body only the status changes, so publishing a draft doesn't need it resent.
+	cleanedBody := dbChirp.Body
+	var flags []moderation.Match
+	if params.Body != nil {
+		validBody, err := validateChirp(*params.Body, cfg.chirpURLLength)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+
+		cleanedBody, flags, err = cfg.moderate(validBody)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
 		}
+	}
 
-		if sortArg == "desc" {
-			sort.Slice(apiChirps, func(i, j int) bool {
-				return apiChirps[i].CreatedAt.After(apiChirps[j].CreatedAt)
+	// Saving the same body again isn't an edit and leaves no revision or new flags.
+	bodyChanged := cleanedBody != dbChirp.Body
+	chirp := dbChirp
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		if bodyChanged {
+			// Nobody else has seen an unpublished chirp, so there's no history worth keeping.
+			if isPublished {
+				err := q.CreateChirpRevision(r.Context(), database.CreateChirpRevisionParams{
+					ChirpID:   chirpID,
+					Body:      dbChirp.Body,
+					CreatedAt: dbChirp.UpdatedAt,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			chirp, err = q.UpdateChirpBody(r.Context(), database.UpdateChirpBodyParams{
+				ID:   chirpID,
+				Body: cleanedBody,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if changeStatus && !isPublished {
+			chirp, err = q.SetChirpStatus(r.Context(), database.SetChirpStatusParams{
+				ID:        chirpID,
+				Status:    status,
+				PublishAt: publishAt,
 			})
-		} else {
-			sort.Slice(apiChirps, func(i, j int) bool {
-				return apiChirps[i].CreatedAt.Before(apiChirps[j].CreatedAt)
+			if err != nil {
+				return err
+			}
+			// The poll's clock starts at publication, so it moves along with it.
+			err = q.ReschedulePoll(r.Context(), database.ReschedulePollParams{
+				CreatedAt:         chirp.CreatedAt,
+				PreviousCreatedAt: dbChirp.CreatedAt,
+				ChirpID:           chirpID,
 			})
+			if err != nil {
+				return err
+			}
 		}
 
-		respondWithJSON(w, http.StatusOK, apiChirps)
+		err = q.DeleteChirpHashtags(r.Context(), chirpID)
+		if err != nil {
+			return err
+		}
+		err = storeHashtags(r.Context(), q, chirp)
+		if err != nil {
+			return err
+		}
+		err = q.DeleteChirpMentions(r.Context(), chirpID)
+		if err != nil {
+			return err
+		}
+		err = storeMentions(r.Context(), q, chirp)
+		if err != nil {
+			return err
+		}
+		if !bodyChanged {
+			return nil
+		}
+		return storeModerationFlags(r.Context(), q, chirp, flags)
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error updating chirp", err)
 		return
 	}
 
-	dbChirps, err := cfg.db.GetChirps(r.Context())
+	apiChirps, err := cfg.databaseChirpsToAPIChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, []database.Chirp{chirp})
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Error getting chirps", err)
+		respondWithError(w, http.StatusInternalServerError, "Error updating chirp", err)
 		return
 	}
 
-	apiChirps := []Chirp{}
-	for _, dbChirp := range dbChirps {
-		apiChirps = append(apiChirps, databaseChirpToAPIChirp(dbChirp))
+	respondWithJSON(w, http.StatusOK, apiChirps[0])
+}
+
+func (cfg *apiConfig) handlerChirpsGetSingle(w http.ResponseWriter, r *http.Request) {
+
+	chirpIDString := r.PathValue("chirpID")
+	chirpID, err := uuid.Parse(chirpIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't parse UUID", err)
+		return
 	}
+	viewerID := cfg.getViewerID(r)
+	dbChirp, err := cfg.getVisibleChirp(r.Context(), viewerID, chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", err)
+		return
+	}
+
+	apiChirps, err := cfg.databaseChirpsToAPIChirps(r.Context(), viewerID, []database.Chirp{dbChirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting chirp", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, apiChirps[0])
+}
+
+func (cfg *apiConfig) handlerChirpsGetAll(w http.ResponseWriter, r *http.Request) {
+	sortArg := r.URL.Query().Get("sort")
+	if sortArg == "" || (sortArg != "asc" && sortArg != "desc") {
+		sortArg = "asc"
+	}
+
+	authorID := uuid.NullUUID{}
+	authorIDString := r.URL.Query().Get("author_id")
+	if authorIDString != "" {
+		userID, err := uuid.Parse(authorIDString)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Couldn't parse UUID", err)
+			return
+		}
+		authorID = uuid.NullUUID{UUID: userID, Valid: true}
+	}
+
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	viewerID := cfg.getViewerID(r)
+	var dbChirps []database.Chirp
 	if sortArg == "desc" {
-		sort.Slice(apiChirps, func(i, j int) bool {
-			return apiChirps[i].CreatedAt.After(apiChirps[j].CreatedAt)
+		dbChirps, err = cfg.db.ListChirpsDesc(r.Context(), database.ListChirpsDescParams{
+			AuthorID:        authorID,
+			CursorCreatedAt: page.CreatedAt,
+			CursorID:        page.ID,
+			ViewerID:        viewerID,
+			PageSize:        int32(page.Limit + 1),
 		})
 	} else {
-		sort.Slice(apiChirps, func(i, j int) bool {
-			return apiChirps[i].CreatedAt.Before(apiChirps[j].CreatedAt)
+		dbChirps, err = cfg.db.ListChirpsAsc(r.Context(), database.ListChirpsAscParams{
+			AuthorID:        authorID,
+			CursorCreatedAt: page.CreatedAt,
+			CursorID:        page.ID,
+			ViewerID:        viewerID,
+			PageSize:        int32(page.Limit + 1),
+		})
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting chirps", err)
+		return
+	}
+
+	apiChirps, err := cfg.databaseChirpsToAPIChirps(r.Context(), viewerID, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting chirps", err)
+		return
+	}
+	chirps := newChirpsPage(apiChirps, page.Limit)
+
+	// An author's pinned chirps head the first page of their listing. They still show up in
+	// order further down as well.
+	if authorID.Valid && !page.CreatedAt.Valid {
+		// An author who doesn't exist has no pins, so the non-Red limit does.
+		author, err := cfg.db.GetUser(r.Context(), authorID.UUID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusInternalServerError, "Error getting pinned chirps", err)
+			return
+		}
+		dbPinned, err := cfg.db.ListPinnedChirps(r.Context(), database.ListPinnedChirpsParams{
+			UserID:   authorID.UUID,
+			ViewerID: viewerID,
+			MaxPins:  int32(maxPinnedChirpsFor(author)),
 		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error getting pinned chirps", err)
+			return
+		}
+		chirps.Pinned, err = cfg.databaseChirpsToAPIChirps(r.Context(), viewerID, dbPinned)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error getting pinned chirps", err)
+			return
+		}
 	}
 
-	respondWithJSON(w, http.StatusOK, apiChirps)
+	respondWithJSON(w, http.StatusOK, chirps)
 }
 
 func (cfg *apiConfig) handlerChirpsCreate(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Body string `json:"body"`
+		Body           string          `json:"body"`
+		InReplyTo      *uuid.UUID      `json:"in_reply_to"`
+		QuoteOf        *uuid.UUID      `json:"quote_of"`
+		MediaIDs       []uuid.UUID     `json:"media_ids"`
+		Poll           *pollParameters `json:"poll"`
+		Status         string          `json:"status"`
+		PublishAt      *time.Time      `json:"publish_at"`
+		Visibility     string          `json:"visibility"`
+		ContentWarning string          `json:"content_warning"`
 	}
 
 	bearerToken, err := auth.GetBearerToken(r.Header)
@@ -167,61 +423,243 @@ func (cfg *apiConfig) handlerChirpsCreate(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	cleanedBody, err := validateChirp(params.Body)
+	validBody, err := validateChirp(params.Body, cfg.chirpURLLength)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	cleanedBody, flags, err := cfg.moderate(validBody)
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, err.Error(), err)
 		return
 	}
 
+	err = validateMediaIDs(params.MediaIDs)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	status, publishAt, err := parseChirpStatus(params.Status, params.PublishAt)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	visibility, err := parseChirpVisibility(params.Visibility)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	validContentWarning, err := validateContentWarning(params.ContentWarning, cfg.chirpURLLength)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	contentWarning, contentWarningFlags, err := cfg.moderate(validContentWarning)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	flags = append(flags, contentWarningFlags...)
+
+	var poll *pollParameters
+	if params.Poll != nil {
+		// A poll's clock starts when the chirp is published, which a draft doesn't know yet.
+		if status == chirpStatusDraft {
+			respondWithError(w, http.StatusBadRequest, "Drafts can't have polls", nil)
+			return
+		}
+		validPoll, err := validatePoll(*params.Poll)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		poll = &validPoll
+	}
+
+	inReplyTo := uuid.NullUUID{}
+	if params.InReplyTo != nil {
+		parent, err := cfg.getShareableChirp(r.Context(), userID, *params.InReplyTo)
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "Chirp to reply to not found", err)
+			return
+		}
+		inReplyTo = uuid.NullUUID{UUID: parent.ID, Valid: true}
+	}
+
+	quoteOf := uuid.NullUUID{}
+	if params.QuoteOf != nil {
+		quoted, err := cfg.getShareableChirp(r.Context(), userID, *params.QuoteOf)
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "Chirp to quote not found", err)
+			return
+		}
+		if quoted.Visibility != chirpVisibilityPublic {
+			respondWithError(w, http.StatusBadRequest, "Only public chirps can be quoted", nil)
+			return
+		}
+		quoteOf = uuid.NullUUID{UUID: quoted.ID, Valid: true}
+	}
+
 	chirpParams := database.CreateChirpParams{
-		Body:   cleanedBody,
-		UserID: userID,
+		Body:           cleanedBody,
+		UserID:         userID,
+		InReplyTo:      inReplyTo,
+		QuoteOf:        quoteOf,
+		Status:         status,
+		PublishAt:      publishAt,
+		Visibility:     visibility,
+		ContentWarning: contentWarning,
 	}
 
-	chirp, err := cfg.db.CreateChirp(r.Context(), chirpParams)
+	var chirp database.Chirp
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		chirp, err = q.CreateChirp(r.Context(), chirpParams)
+		if err != nil {
+			return err
+		}
+		err = storeHashtags(r.Context(), q, chirp)
+		if err != nil {
+			return err
+		}
+		err = storeMentions(r.Context(), q, chirp)
+		if err != nil {
+			return err
+		}
+		err = attachMedia(r.Context(), q, chirp, params.MediaIDs)
+		if err != nil {
+			return err
+		}
+		err = storeModerationFlags(r.Context(), q, chirp, flags)
+		if err != nil {
+			return err
+		}
+		if poll == nil {
+			return nil
+		}
+		return storePoll(r.Context(), q, chirp, *poll)
+	})
+	if errors.Is(err, errMediaUnavailable) {
+		respondWithError(w, http.StatusBadRequest, "Media not found or already attached", err)
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error creating chirp", err)
 		return
 	}
 
-	apiChirp := databaseChirpToAPIChirp(chirp)
+	apiChirps, err := cfg.databaseChirpsToAPIChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, []database.Chirp{chirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error creating chirp", err)
+		return
+	}
 
-	respondWithJSON(w, http.StatusCreated, apiChirp)
+	respondWithJSON(w, http.StatusCreated, apiChirps[0])
 }
 
 func databaseChirpToAPIChirp(dbChirp database.Chirp) Chirp {
-	return Chirp{
-		ID:        dbChirp.ID,
-		CreatedAt: dbChirp.CreatedAt,
-		UpdatedAt: dbChirp.UpdatedAt,
-		Body:      dbChirp.Body,
-		UserID:    dbChirp.UserID,
+	chirp := Chirp{
+		ID:             dbChirp.ID,
+		CreatedAt:      dbChirp.CreatedAt,
+		UpdatedAt:      dbChirp.UpdatedAt,
+		ContentWarning: dbChirp.ContentWarning,
+		Body:           dbChirp.Body,
+		UserID:         dbChirp.UserID,
+		IsDeleted:      dbChirp.DeletedAt.Valid,
+		Status:         dbChirp.Status,
+		Visibility:     dbChirp.Visibility,
 	}
+	// Deleted chirps still show up as tombstones in threads and quotes, without their content.
+	if chirp.IsDeleted {
+		chirp.ContentWarning = ""
+		chirp.Body = ""
+	}
+	if dbChirp.InReplyTo.Valid {
+		inReplyTo := dbChirp.InReplyTo.UUID
+		chirp.InReplyTo = &inReplyTo
+	}
+	if dbChirp.PublishAt.Valid {
+		publishAt := dbChirp.PublishAt.Time
+		chirp.PublishAt = &publishAt
+	}
+	return chirp
+}
+
+// databaseChirpsToAPIChirps converts a batch of chirps and loads everything derived from other tables
+// with one query per feature rather than one per chirp. Per-viewer fields are only filled in when
+// viewerID is set.
+func (cfg *apiConfig) databaseChirpsToAPIChirps(ctx context.Context, viewerID uuid.NullUUID, dbChirps []database.Chirp) ([]Chirp, error) {
+	apiChirps, err := cfg.decorateChirps(ctx, viewerID, dbChirps)
+	if err != nil {
+		return nil, err
+	}
+	err = cfg.embedSharedChirps(ctx, viewerID, dbChirps, apiChirps)
+	if err != nil {
+		return nil, err
+	}
+	return apiChirps, nil
 }
 
-func validateChirp(body string) (string, error) {
+func (cfg *apiConfig) decorateChirps(ctx context.Context, viewerID uuid.NullUUID, dbChirps []database.Chirp) ([]Chirp, error) {
+	apiChirps := []Chirp{}
+	for _, dbChirp := range dbChirps {
+		apiChirps = append(apiChirps, databaseChirpToAPIChirp(dbChirp))
+	}
+	if len(apiChirps) == 0 {
+		return apiChirps, nil
+	}
+
+	decorators := []func(context.Context, uuid.NullUUID, []Chirp) error{
+		cfg.addReplyCounts,
+		cfg.addLikes,
+		cfg.addBookmarks,
+		cfg.addShareCounts,
+		cfg.addEntities,
+		cfg.addAttachments,
+		cfg.addPolls,
+	}
+	for _, decorate := range decorators {
+		err := decorate(ctx, viewerID, apiChirps)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return apiChirps, nil
+}
+
+func chirpIDs(apiChirps []Chirp) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(apiChirps))
+	for _, apiChirp := range apiChirps {
+		ids = append(ids, apiChirp.ID)
+	}
+	return ids
+}
+
+// validateChirp normalizes a chirp body and checks its length in user-perceived characters.
+// Links count as urlLength characters.
+func validateChirp(body string, urlLength int) (string, error) {
 	const maxChirpLength = 140
-	if len(body) > maxChirpLength {
+	body = chirptext.Normalize(body)
+	if chirptext.Length(body, urlLength) > maxChirpLength {
 		return "", errors.New("Chirp is too long")
 	}
 
-	badWords := map[string]struct{}{
-		"kerfuffle": {},
-		"sharbert":  {},
-		"fornax":    {},
-	}
-	cleaned := getCleanedBody(body, badWords)
-	return cleaned, nil
+	return body, nil
 }
 
-func getCleanedBody(body string, badWords map[string]struct{}) string {
-	words := strings.Split(body, " ")
-	for i, word := range words {
-		loweredWord := strings.ToLower(word)
-		if _, ok := badWords[loweredWord]; ok {
-			words[i] = "****"
-		}
+// validateContentWarning checks the optional summary shown in place of a collapsed body. It has
+// its own limit on top of the body's.
+func validateContentWarning(contentWarning string, urlLength int) (string, error) {
+	const maxContentWarningLength = 100
+	contentWarning = strings.TrimSpace(chirptext.Normalize(contentWarning))
+	if chirptext.Length(contentWarning, urlLength) > maxContentWarningLength {
+		return "", errors.New("Content warning is too long")
 	}
-	cleaned := strings.Join(words, " ")
-	return cleaned
+
+	return contentWarning, nil
 }
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/docherak/bd-chirpy/internal/database"
+	"github.com/lib/pq"
+)
+
+// withTx runs fn inside a transaction, committing if it returns nil and rolling back otherwise.
+func (cfg *apiConfig) withTx(ctx context.Context, fn func(q *database.Queries) error) error {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = fn(cfg.db.WithTx(tx))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/docherak/bd-chirpy/internal/auth"
+	"github.com/docherak/bd-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// Drafts and scheduled chirps are only visible to their author. A scheduled chirp's created_at
+// is the time it will be published, so it lands in feeds at that point.
+const (
+	chirpStatusDraft     = "draft"
+	chirpStatusScheduled = "scheduled"
+	chirpStatusPublished = "published"
+)
+
+const maxScheduleAhead = 365 * 24 * time.Hour
+
+// parseChirpStatus works out the status of a chirp from the requested status and publish_at.
+// A publish_at on its own schedules the chirp.
+func parseChirpStatus(status string, publishAt *time.Time) (string, sql.NullTime, error) {
+	switch status {
+	case chirpStatusDraft:
+		if publishAt != nil {
+			return "", sql.NullTime{}, errors.New("Drafts can't have a publish_at")
+		}
+		return chirpStatusDraft, sql.NullTime{}, nil
+	case "", chirpStatusPublished, chirpStatusScheduled:
+		if publishAt == nil {
+			if status == chirpStatusScheduled {
+				return "", sql.NullTime{}, errors.New("Scheduled chirps need a publish_at")
+			}
+			return chirpStatusPublished, sql.NullTime{}, nil
+		}
+		untilPublish := time.Until(*publishAt)
+		if untilPublish <= 0 {
+			return "", sql.NullTime{}, errors.New("publish_at must be in the future")
+		}
+		if untilPublish > maxScheduleAhead {
+			return "", sql.NullTime{}, errors.New("publish_at is too far in the future")
+		}
+		return chirpStatusScheduled, sql.NullTime{Time: publishAt.UTC(), Valid: true}, nil
+	default:
+		return "", sql.NullTime{}, errors.New("Unknown status")
+	}
+}
+
+func (cfg *apiConfig) handlerDraftsGet(w http.ResponseWriter, r *http.Request) {
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error getting bearer token", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	dbChirps, err := cfg.db.ListDraftChirps(r.Context(), database.ListDraftChirpsParams{
+		UserID:          userID,
+		CursorCreatedAt: page.CreatedAt,
+		CursorID:        page.ID,
+		PageSize:        int32(page.Limit + 1),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting drafts", err)
+		return
+	}
+
+	apiChirps, err := cfg.databaseChirpsToAPIChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting drafts", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newChirpsPage(apiChirps, page.Limit))
+}
+
+func (cfg *apiConfig) handlerScheduledGet(w http.ResponseWriter, r *http.Request) {
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error getting bearer token", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	dbChirps, err := cfg.db.ListScheduledChirps(r.Context(), database.ListScheduledChirpsParams{
+		UserID:          userID,
+		CursorPublishAt: page.CreatedAt,
+		CursorID:        page.ID,
+		PageSize:        int32(page.Limit + 1),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting scheduled chirps", err)
+		return
+	}
+
+	apiChirps, err := cfg.databaseChirpsToAPIChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting scheduled chirps", err)
+		return
+	}
+
+	// Scheduled chirps come soonest first, so the cursor points at the publish time.
+	chirps := newChirpsPage(apiChirps, page.Limit)
+	if chirps.NextCursor != "" {
+		last := dbChirps[page.Limit-1]
+		chirps.NextCursor = encodeCursor(last.PublishAt.Time, last.ID)
+	}
+
+	respondWithJSON(w, http.StatusOK, chirps)
+}
+
+// publishScheduledChirps makes scheduled chirps whose time has come visible to everyone.
+func (cfg *apiConfig) publishScheduledChirps(ctx context.Context) error {
+	published, err := cfg.db.PublishScheduledChirps(ctx, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if published > 0 {
+		log.Printf("Published %d scheduled chirps", published)
+	}
+	return nil
+}
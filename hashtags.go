@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/docherak/bd-chirpy/internal/database"
+	"github.com/docherak/bd-chirpy/internal/entities"
+)
+
+const (
+	maxTrends          = 10
+	minTrendChirpCount = 3
+)
+
+type Trend struct {
+	Tag        string    `json:"tag"`
+	ChirpCount int64     `json:"chirp_count"`
+	Score      float64   `json:"score"`
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+func (cfg *apiConfig) handlerHashtagChirpsGet(w http.ResponseWriter, r *http.Request) {
+	tag := entities.NormalizeTag(r.PathValue("tag"))
+
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	viewerID := cfg.getViewerID(r)
+	dbChirps, err := cfg.db.ListChirpsByHashtag(r.Context(), database.ListChirpsByHashtagParams{
+		Tag:             tag,
+		CursorCreatedAt: page.CreatedAt,
+		CursorID:        page.ID,
+		ViewerID:        viewerID,
+		PageSize:        int32(page.Limit + 1),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting chirps", err)
+		return
+	}
+
+	apiChirps, err := cfg.databaseChirpsToAPIChirps(r.Context(), viewerID, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting chirps", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newChirpsPage(apiChirps, page.Limit))
+}
+
+func (cfg *apiConfig) handlerTrendsGet(w http.ResponseWriter, r *http.Request) {
+	rows, err := cfg.db.ListTrendingHashtags(r.Context(), maxTrends)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting trends", err)
+		return
+	}
+
+	trends := []Trend{}
+	for _, row := range rows {
+		trends = append(trends, Trend{
+			Tag:        row.Tag,
+			ChirpCount: row.RecentCount,
+			Score:      row.Score,
+			ComputedAt: row.ComputedAt,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, trends)
+}
+
+// storeHashtags links a chirp to every distinct hashtag in its body.
+func storeHashtags(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	seen := map[string]bool{}
+	for _, hashtag := range entities.ParseHashtags(chirp.Body) {
+		tag := entities.NormalizeTag(hashtag.Text)
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+
+		dbHashtag, err := q.UpsertHashtag(ctx, tag)
+		if err != nil {
+			return err
+		}
+		err = q.AddChirpHashtag(ctx, database.AddChirpHashtagParams{
+			ChirpID:   chirp.ID,
+			HashtagID: dbHashtag.ID,
+			CreatedAt: chirp.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// computeTrends compares hashtag usage in the last window with the window before it and stores
+// the tags that grew the most, so GET /api/trends only reads a small precomputed table.
+func (cfg *apiConfig) computeTrends(ctx context.Context, window time.Duration) error {
+	now := time.Now().UTC()
+	return cfg.withTx(ctx, func(q *database.Queries) error {
+		err := q.ClearTrendingHashtags(ctx)
+		if err != nil {
+			return err
+		}
+		return q.ComputeTrendingHashtags(ctx, database.ComputeTrendingHashtagsParams{
+			RecentSince:   now.Add(-window),
+			PreviousSince: now.Add(-2 * window),
+			MinCount:      minTrendChirpCount,
+		})
+	})
+}
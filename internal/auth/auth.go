@@ -83,7 +83,7 @@ func GetBearerToken(headers http.Header) (string, error) {
 		return "", errors.New("Authorization header is empty")
 	}
 	parts := strings.Split(authHeader, " ")
-	if len(parts) < 2 || parts[0] != "Bearer" {
+	if len(parts) < 2 || parts[0] != "Bearer" || parts[1] == "" {
 		return "", errors.New("Malformed authorization header")
 	}
 	return parts[1], nil
@@ -0,0 +1,124 @@
+package chirptext
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+)
+
+const masked = "****"
+
+// Token is a word in chirp text. Start and End are byte offsets, End being exclusive.
+type Token struct {
+	Text  string
+	Start int
+	End   int
+}
+
+// Normalize puts text into NFC form, so the same characters are always stored the same way
+// whether they were typed precomposed or with combining marks, and turns CRLF into LF.
+func Normalize(text string) string {
+	return norm.NFC.String(strings.ReplaceAll(text, "\r\n", "\n"))
+}
+
+// Length counts what a reader sees as characters, so an emoji built from several code points
+// counts once. When urlLength is positive every link counts as that many characters,
+// whatever its actual length.
+func Length(text string, urlLength int) int {
+	if urlLength <= 0 {
+		return uniseg.GraphemeClusterCount(text)
+	}
+	length := 0
+	prev := 0
+	for _, link := range Links(text) {
+		length += uniseg.GraphemeClusterCount(text[prev:link.Start]) + urlLength
+		prev = link.End
+	}
+	return length + uniseg.GraphemeClusterCount(text[prev:])
+}
+
+// Words splits text into runs of letters, digits and marks. Everything else, including
+// punctuation, newlines and tabs, separates words. Apostrophes inside a word are kept.
+func Words(text string) []Token {
+	words := []Token{}
+	start := -1
+	for i, r := range text {
+		if isWordRune(r) || (start >= 0 && r == '\'' && isWordStart(text[i+1:])) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			words = append(words, Token{Text: text[start:i], Start: start, End: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		words = append(words, Token{Text: text[start:], Start: start, End: len(text)})
+	}
+	return words
+}
+
+// Mask replaces each span of text with asterisks. Spans must be in order and not overlap.
+func Mask(text string, spans []Token) string {
+	var b strings.Builder
+	prev := 0
+	for _, span := range spans {
+		b.WriteString(text[prev:span.Start])
+		b.WriteString(masked)
+		prev = span.End
+	}
+	b.WriteString(text[prev:])
+	return b.String()
+}
+
+// Inside reports whether token lies entirely within one of spans.
+func Inside(token Token, spans []Token) bool {
+	for _, span := range spans {
+		if token.Start >= span.Start && token.End <= span.End {
+			return true
+		}
+	}
+	return false
+}
+
+// Links finds http and https URLs. A link runs to the next whitespace, minus any
+// punctuation that ends the sentence it's in.
+func Links(text string) []Token {
+	links := []Token{}
+	for i := 0; i < len(text); {
+		rest := text[i:]
+		if !(strings.HasPrefix(rest, "http://") || strings.HasPrefix(rest, "https://")) ||
+			(i > 0 && !isLinkBoundary(text[:i])) {
+			_, size := utf8.DecodeRuneInString(rest)
+			i += size
+			continue
+		}
+		end := strings.IndexFunc(rest, unicode.IsSpace)
+		if end < 0 {
+			end = len(rest)
+		}
+		link := strings.TrimRight(rest[:end], ".,;:!?)]}\"'")
+		links = append(links, Token{Text: link, Start: i, End: i + len(link)})
+		i += end
+	}
+	return links
+}
+
+func isLinkBoundary(text string) bool {
+	r, _ := utf8.DecodeLastRuneInString(text)
+	return unicode.IsSpace(r) || unicode.IsPunct(r)
+}
+
+func isWordStart(text string) bool {
+	r, _ := utf8.DecodeRuneInString(text)
+	return isWordRune(r)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || r == '_'
+}
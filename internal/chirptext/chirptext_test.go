@@ -0,0 +1,106 @@
+package chirptext
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	// "é" as "e" followed by a combining acute accent.
+	got := Normalize("cafe\u0301\r\nbar")
+	want := "caf\u00e9\nbar"
+	if got != want {
+		t.Errorf("Normalize() = %q, want %q", got, want)
+	}
+}
+
+func TestLength(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		urlLength int
+		want      int
+	}{
+		{
+			name: "ASCII",
+			text: "hello",
+			want: 5,
+		},
+		{
+			name: "Emoji count once",
+			text: strings.Repeat("😀", 50),
+			want: 50,
+		},
+		{
+			name: "Family emoji is one grapheme",
+			text: "👨‍👩‍👧",
+			want: 1,
+		},
+		{
+			name: "Combining marks",
+			text: "cafe\u0301",
+			want: 4,
+		},
+		{
+			name:      "Links count as a fixed length",
+			text:      "see https://example.com/a/very/long/path/indeed.",
+			urlLength: 23,
+			want:      4 + 23 + 1,
+		},
+		{
+			name: "Links count as-is without weighting",
+			text: "see https://example.com",
+			want: 23,
+		},
+		{
+			name:      "Scheme in the middle of a word is not a link",
+			text:      "xhttps://a",
+			urlLength: 23,
+			want:      10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Length(tt.text, tt.urlLength)
+			if got != tt.want {
+				t.Errorf("Length() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWords(t *testing.T) {
+	got := Words("Don't\tstop,\nčau!")
+	want := []Token{
+		{Text: "Don't", Start: 0, End: 5},
+		{Text: "stop", Start: 6, End: 10},
+		{Text: "čau", Start: 12, End: 16},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Words() = %+v, want %+v", got, want)
+	}
+}
+
+func TestLinks(t *testing.T) {
+	got := Links("see https://example.com/a, or (http://b.example) but not xhttps://c")
+	want := []Token{
+		{Text: "https://example.com/a", Start: 4, End: 25},
+		{Text: "http://b.example", Start: 31, End: 47},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Links() = %+v, want %+v", got, want)
+	}
+}
+
+func TestMask(t *testing.T) {
+	got := Mask("What a mess, really!", []Token{
+		{Text: "mess", Start: 7, End: 11},
+		{Text: "really", Start: 13, End: 19},
+	})
+	want := "What a ****, ****!"
+	if got != want {
+		t.Errorf("Mask() = %q, want %q", got, want)
+	}
+}
@@ -7,25 +7,180 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const countQuotesForChirps = `-- name: CountQuotesForChirps :many
+SELECT quote_of, COUNT(*) AS quote_count FROM chirps
+WHERE quote_of = ANY($1::uuid[])
+AND deleted_at IS NULL
+AND status = 'published'
+AND chirp_visible_to(id, user_id, visibility, $2::uuid)
+GROUP BY quote_of
+`
+
+type CountQuotesForChirpsParams struct {
+	ChirpIds []uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+type CountQuotesForChirpsRow struct {
+	QuoteOf    uuid.NullUUID
+	QuoteCount int64
+}
+
+func (q *Queries) CountQuotesForChirps(ctx context.Context, arg CountQuotesForChirpsParams) ([]CountQuotesForChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, countQuotesForChirps, pq.Array(arg.ChirpIds), arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountQuotesForChirpsRow
+	for rows.Next() {
+		var i CountQuotesForChirpsRow
+		if err := rows.Scan(
+			&i.QuoteOf,
+			&i.QuoteCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countRechirpsForChirps = `-- name: CountRechirpsForChirps :many
+SELECT rechirp_of, COUNT(*) AS rechirp_count FROM chirps
+WHERE rechirp_of = ANY($1::uuid[])
+AND deleted_at IS NULL
+AND status = 'published'
+AND chirp_visible_to(id, user_id, visibility, $2::uuid)
+GROUP BY rechirp_of
+`
+
+type CountRechirpsForChirpsParams struct {
+	ChirpIds []uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+type CountRechirpsForChirpsRow struct {
+	RechirpOf    uuid.NullUUID
+	RechirpCount int64
+}
+
+func (q *Queries) CountRechirpsForChirps(ctx context.Context, arg CountRechirpsForChirpsParams) ([]CountRechirpsForChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, countRechirpsForChirps, pq.Array(arg.ChirpIds), arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountRechirpsForChirpsRow
+	for rows.Next() {
+		var i CountRechirpsForChirpsRow
+		if err := rows.Scan(
+			&i.RechirpOf,
+			&i.RechirpCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countRepliesForChirps = `-- name: CountRepliesForChirps :many
+SELECT in_reply_to, COUNT(*) AS reply_count FROM chirps
+WHERE in_reply_to = ANY($1::uuid[])
+AND deleted_at IS NULL
+AND status = 'published'
+AND chirp_visible_to(id, user_id, visibility, $2::uuid)
+GROUP BY in_reply_to
+`
+
+type CountRepliesForChirpsParams struct {
+	ChirpIds []uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+type CountRepliesForChirpsRow struct {
+	InReplyTo  uuid.NullUUID
+	ReplyCount int64
+}
+
+func (q *Queries) CountRepliesForChirps(ctx context.Context, arg CountRepliesForChirpsParams) ([]CountRepliesForChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, countRepliesForChirps, pq.Array(arg.ChirpIds), arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountRepliesForChirpsRow
+	for rows.Next() {
+		var i CountRepliesForChirpsRow
+		if err := rows.Scan(
+			&i.InReplyTo,
+			&i.ReplyCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to, quote_of, status, publish_at, visibility, content_warning)
 VALUES (
-    gen_random_uuid(), NOW(), NOW(), $1, $2
+    gen_random_uuid(), COALESCE($1::timestamp, NOW()), NOW(),
+    $2, $3, $4, $5,
+    $6, $1, $7, $8
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to, deleted_at, rechirp_of, quote_of, status, publish_at, visibility, content_warning, search_vector, scrubbed_at
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	PublishAt      sql.NullTime
+	Body           string
+	UserID         uuid.UUID
+	InReplyTo      uuid.NullUUID
+	QuoteOf        uuid.NullUUID
+	Status         string
+	Visibility     string
+	ContentWarning string
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.PublishAt,
+		arg.Body,
+		arg.UserID,
+		arg.InReplyTo,
+		arg.QuoteOf,
+		arg.Status,
+		arg.Visibility,
+		arg.ContentWarning,
+	)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -33,10 +188,38 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.InReplyTo,
+		&i.DeletedAt,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.Status,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.SearchVector,
+		&i.ScrubbedAt,
 	)
 	return i, err
 }
 
+const createRechirp = `-- name: CreateRechirp :exec
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, rechirp_of)
+VALUES (
+    gen_random_uuid(), NOW(), NOW(), '', $1, $2
+)
+ON CONFLICT (user_id, rechirp_of) WHERE rechirp_of IS NOT NULL DO NOTHING
+`
+
+type CreateRechirpParams struct {
+	UserID    uuid.UUID
+	RechirpOf uuid.NullUUID
+}
+
+func (q *Queries) CreateRechirp(ctx context.Context, arg CreateRechirpParams) error {
+	_, err := q.db.ExecContext(ctx, createRechirp, arg.UserID, arg.RechirpOf)
+	return err
+}
+
 const deleteChirp = `-- name: DeleteChirp :exec
 DELETE FROM chirps
 WHERE id = $1
@@ -47,9 +230,25 @@ func (q *Queries) DeleteChirp(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const deleteRechirp = `-- name: DeleteRechirp :exec
+DELETE FROM chirps
+WHERE user_id = $1 AND rechirp_of = $2
+`
+
+type DeleteRechirpParams struct {
+	UserID    uuid.UUID
+	RechirpOf uuid.NullUUID
+}
+
+func (q *Queries) DeleteRechirp(ctx context.Context, arg DeleteRechirpParams) error {
+	_, err := q.db.ExecContext(ctx, deleteRechirp, arg.UserID, arg.RechirpOf)
+	return err
+}
+
 const getChirp = `-- name: GetChirp :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, deleted_at, rechirp_of, quote_of, status, publish_at, visibility, content_warning, search_vector, scrubbed_at FROM chirps
 WHERE id = $1
+AND deleted_at IS NULL
 `
 
 func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
@@ -61,17 +260,359 @@ func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.InReplyTo,
+		&i.DeletedAt,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.Status,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.SearchVector,
+		&i.ScrubbedAt,
 	)
 	return i, err
 }
 
-const getChirps = `-- name: GetChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-ORDER BY created_at ASC
+const getChirpWithDeleted = `-- name: GetChirpWithDeleted :one
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, deleted_at, rechirp_of, quote_of, status, publish_at, visibility, content_warning, search_vector, scrubbed_at FROM chirps
+WHERE id = $1
+`
+
+func (q *Queries) GetChirpWithDeleted(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpWithDeleted, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.DeletedAt,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.Status,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.SearchVector,
+		&i.ScrubbedAt,
+	)
+	return i, err
+}
+
+const getChirpsByIDs = `-- name: GetChirpsByIDs :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, deleted_at, rechirp_of, quote_of, status, publish_at, visibility, content_warning, search_vector, scrubbed_at FROM chirps
+WHERE id = ANY($1::uuid[])
+AND chirp_visible_to(id, user_id, visibility, $2::uuid)
+`
+
+type GetChirpsByIDsParams struct {
+	ChirpIds []uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetChirpsByIDs(ctx context.Context, arg GetChirpsByIDsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIDs, pq.Array(arg.ChirpIds), arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.DeletedAt,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.Status,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.SearchVector,
+			&i.ScrubbedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const isChirpVisibleTo = `-- name: IsChirpVisibleTo :one
+SELECT chirp_visible_to(id, user_id, visibility, $1::uuid) FROM chirps
+WHERE id = $2
+`
+
+type IsChirpVisibleToParams struct {
+	ViewerID uuid.NullUUID
+	ChirpID  uuid.UUID
+}
+
+func (q *Queries) IsChirpVisibleTo(ctx context.Context, arg IsChirpVisibleToParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isChirpVisibleTo, arg.ViewerID, arg.ChirpID)
+	var chirp_visible_to bool
+	err := row.Scan(&chirp_visible_to)
+	return chirp_visible_to, err
+}
+
+const listChirpAncestors = `-- name: ListChirpAncestors :many
+WITH RECURSIVE ancestors (id, in_reply_to, depth) AS (
+    SELECT c.id, c.in_reply_to, 0 FROM chirps c
+    WHERE c.id = $1
+    UNION ALL
+    SELECT c.id, c.in_reply_to, a.depth + 1 FROM chirps c
+    JOIN ancestors a ON c.id = a.in_reply_to
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to, chirps.deleted_at, chirps.rechirp_of, chirps.quote_of, chirps.status, chirps.publish_at, chirps.visibility, chirps.content_warning, chirps.search_vector, chirps.scrubbed_at FROM chirps
+JOIN ancestors ON ancestors.id = chirps.id
+WHERE ancestors.depth > 0
+AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, $2::uuid)
+ORDER BY ancestors.depth DESC
+`
+
+type ListChirpAncestorsParams struct {
+	ChirpID  uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) ListChirpAncestors(ctx context.Context, arg ListChirpAncestorsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpAncestors, arg.ChirpID, arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.DeletedAt,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.Status,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.SearchVector,
+			&i.ScrubbedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpDescendants = `-- name: ListChirpDescendants :many
+WITH RECURSIVE descendants (id, depth) AS (
+    SELECT c.id, 1 FROM chirps c
+    WHERE c.in_reply_to = $1
+    AND c.status = 'published'
+    AND chirp_visible_to(c.id, c.user_id, c.visibility, $2::uuid)
+    UNION ALL
+    SELECT c.id, d.depth + 1 FROM chirps c
+    JOIN descendants d ON c.in_reply_to = d.id
+    WHERE d.depth < $3::int
+    AND c.status = 'published'
+    AND chirp_visible_to(c.id, c.user_id, c.visibility, $2::uuid)
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.in_reply_to, chirps.deleted_at, chirps.rechirp_of, chirps.quote_of, chirps.status, chirps.publish_at, chirps.visibility, chirps.content_warning, chirps.search_vector, chirps.scrubbed_at FROM chirps
+JOIN descendants ON descendants.id = chirps.id
+ORDER BY chirps.created_at ASC, chirps.id ASC
+LIMIT $4
+`
+
+type ListChirpDescendantsParams struct {
+	ChirpID   uuid.UUID
+	ViewerID  uuid.NullUUID
+	MaxDepth  int32
+	MaxChirps int32
+}
+
+func (q *Queries) ListChirpDescendants(ctx context.Context, arg ListChirpDescendantsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpDescendants,
+		arg.ChirpID,
+		arg.ViewerID,
+		arg.MaxDepth,
+		arg.MaxChirps,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.DeletedAt,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.Status,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.SearchVector,
+			&i.ScrubbedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsAsc = `-- name: ListChirpsAsc :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, deleted_at, rechirp_of, quote_of, status, publish_at, visibility, content_warning, search_vector, scrubbed_at FROM chirps
+WHERE deleted_at IS NULL
+AND status = 'published'
+AND ($1::uuid IS NULL OR user_id = $1::uuid)
+AND (
+    $2::timestamp IS NULL
+    OR (created_at, id) > ($2::timestamp, $3::uuid)
+)
+AND chirp_visible_to(id, user_id, visibility, $4::uuid)
+AND NOT chirp_muted_for(user_id, content_warning, body, $4::uuid)
+AND (
+    $1::uuid IS NOT NULL
+    OR NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.user_id = $4::uuid
+        AND mutes.muted_id = chirps.user_id
+    )
+)
+ORDER BY created_at ASC, id ASC
+LIMIT $5
+`
+
+type ListChirpsAscParams struct {
+	AuthorID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	PageSize        int32
+}
+
+func (q *Queries) ListChirpsAsc(ctx context.Context, arg ListChirpsAscParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsAsc,
+		arg.AuthorID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.ViewerID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.DeletedAt,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.Status,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.SearchVector,
+			&i.ScrubbedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsDesc = `-- name: ListChirpsDesc :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, deleted_at, rechirp_of, quote_of, status, publish_at, visibility, content_warning, search_vector, scrubbed_at FROM chirps
+WHERE deleted_at IS NULL
+AND status = 'published'
+AND ($1::uuid IS NULL OR user_id = $1::uuid)
+AND (
+    $2::timestamp IS NULL
+    OR (created_at, id) < ($2::timestamp, $3::uuid)
+)
+AND chirp_visible_to(id, user_id, visibility, $4::uuid)
+AND NOT chirp_muted_for(user_id, content_warning, body, $4::uuid)
+AND (
+    $1::uuid IS NOT NULL
+    OR NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.user_id = $4::uuid
+        AND mutes.muted_id = chirps.user_id
+    )
+)
+ORDER BY created_at DESC, id DESC
+LIMIT $5
 `
 
-func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirps)
+type ListChirpsDescParams struct {
+	AuthorID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	PageSize        int32
+}
+
+func (q *Queries) ListChirpsDesc(ctx context.Context, arg ListChirpsDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsDesc,
+		arg.AuthorID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.ViewerID,
+		arg.PageSize,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -85,6 +626,16 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.InReplyTo,
+			&i.DeletedAt,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.Status,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.SearchVector,
+			&i.ScrubbedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -99,14 +650,34 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 	return items, nil
 }
 
-const getChirpsByUser = `-- name: GetChirpsByUser :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+const listDeletedChirps = `-- name: ListDeletedChirps :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, deleted_at, rechirp_of, quote_of, status, publish_at, visibility, content_warning, search_vector, scrubbed_at FROM chirps
 WHERE user_id = $1
-ORDER BY created_at ASC
+AND deleted_at IS NOT NULL
+AND rechirp_of IS NULL
+AND scrubbed_at IS NULL
+AND (
+    $2::timestamp IS NULL
+    OR (deleted_at, id) < ($2::timestamp, $3::uuid)
+)
+ORDER BY deleted_at DESC, id DESC
+LIMIT $4
 `
 
-func (q *Queries) GetChirpsByUser(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirpsByUser, userID)
+type ListDeletedChirpsParams struct {
+	UserID          uuid.UUID
+	CursorDeletedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageSize        int32
+}
+
+func (q *Queries) ListDeletedChirps(ctx context.Context, arg ListDeletedChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listDeletedChirps,
+		arg.UserID,
+		arg.CursorDeletedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -120,6 +691,16 @@ func (q *Queries) GetChirpsByUser(ctx context.Context, userID uuid.UUID) ([]Chir
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.InReplyTo,
+			&i.DeletedAt,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.Status,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.SearchVector,
+			&i.ScrubbedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -133,3 +714,320 @@ func (q *Queries) GetChirpsByUser(ctx context.Context, userID uuid.UUID) ([]Chir
 	}
 	return items, nil
 }
+
+const listDraftChirps = `-- name: ListDraftChirps :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, deleted_at, rechirp_of, quote_of, status, publish_at, visibility, content_warning, search_vector, scrubbed_at FROM chirps
+WHERE user_id = $1
+AND status = 'draft'
+AND deleted_at IS NULL
+AND (
+    $2::timestamp IS NULL
+    OR (created_at, id) < ($2::timestamp, $3::uuid)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT $4
+`
+
+type ListDraftChirpsParams struct {
+	UserID          uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageSize        int32
+}
+
+func (q *Queries) ListDraftChirps(ctx context.Context, arg ListDraftChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listDraftChirps,
+		arg.UserID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.DeletedAt,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.Status,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.SearchVector,
+			&i.ScrubbedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listScheduledChirps = `-- name: ListScheduledChirps :many
+SELECT id, created_at, updated_at, body, user_id, in_reply_to, deleted_at, rechirp_of, quote_of, status, publish_at, visibility, content_warning, search_vector, scrubbed_at FROM chirps
+WHERE user_id = $1
+AND status = 'scheduled'
+AND deleted_at IS NULL
+AND (
+    $2::timestamp IS NULL
+    OR (publish_at, id) > ($2::timestamp, $3::uuid)
+)
+ORDER BY publish_at ASC, id ASC
+LIMIT $4
+`
+
+type ListScheduledChirpsParams struct {
+	UserID          uuid.UUID
+	CursorPublishAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageSize        int32
+}
+
+func (q *Queries) ListScheduledChirps(ctx context.Context, arg ListScheduledChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listScheduledChirps,
+		arg.UserID,
+		arg.CursorPublishAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.InReplyTo,
+			&i.DeletedAt,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.Status,
+			&i.PublishAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.SearchVector,
+			&i.ScrubbedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const publishScheduledChirps = `-- name: PublishScheduledChirps :execrows
+UPDATE chirps SET status = 'published', updated_at = NOW()
+WHERE status = 'scheduled'
+AND publish_at <= $1::timestamp
+`
+
+func (q *Queries) PublishScheduledChirps(ctx context.Context, now time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, publishScheduledChirps, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const purgeDeletedChirps = `-- name: PurgeDeletedChirps :execrows
+DELETE FROM chirps
+WHERE deleted_at < $1::timestamp
+AND NOT EXISTS (
+    SELECT 1 FROM chirps AS refs
+    WHERE refs.in_reply_to = chirps.id OR refs.quote_of = chirps.id
+)
+`
+
+func (q *Queries) PurgeDeletedChirps(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedChirps, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreChirp = `-- name: RestoreChirp :one
+UPDATE chirps SET deleted_at = NULL
+WHERE id = $1
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to, deleted_at, rechirp_of, quote_of, status, publish_at, visibility, content_warning, search_vector, scrubbed_at
+`
+
+func (q *Queries) RestoreChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, restoreChirp, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.DeletedAt,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.Status,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.SearchVector,
+		&i.ScrubbedAt,
+	)
+	return i, err
+}
+
+const restoreRechirpsOf = `-- name: RestoreRechirpsOf :exec
+UPDATE chirps AS rechirps SET deleted_at = NULL
+FROM chirps AS originals
+WHERE originals.id = $1
+AND rechirps.rechirp_of = originals.id
+AND rechirps.deleted_at = originals.deleted_at
+`
+
+func (q *Queries) RestoreRechirpsOf(ctx context.Context, chirpID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, restoreRechirpsOf, chirpID)
+	return err
+}
+
+const scrubChirp = `-- name: ScrubChirp :exec
+UPDATE chirps SET body = '', content_warning = '', deleted_at = COALESCE(deleted_at, NOW()), scrubbed_at = NOW(), updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) ScrubChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, scrubChirp, id)
+	return err
+}
+
+const scrubDeletedChirps = `-- name: ScrubDeletedChirps :execrows
+UPDATE chirps SET body = '', content_warning = '', scrubbed_at = NOW(), updated_at = NOW()
+WHERE deleted_at < $1::timestamp
+AND scrubbed_at IS NULL
+`
+
+func (q *Queries) ScrubDeletedChirps(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, scrubDeletedChirps, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setChirpStatus = `-- name: SetChirpStatus :one
+UPDATE chirps
+SET status = $1, publish_at = $2,
+    created_at = COALESCE($2::timestamp, NOW()), updated_at = NOW()
+WHERE id = $3
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to, deleted_at, rechirp_of, quote_of, status, publish_at, visibility, content_warning, search_vector, scrubbed_at
+`
+
+type SetChirpStatusParams struct {
+	Status    string
+	PublishAt sql.NullTime
+	ID        uuid.UUID
+}
+
+func (q *Queries) SetChirpStatus(ctx context.Context, arg SetChirpStatusParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, setChirpStatus, arg.Status, arg.PublishAt, arg.ID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.DeletedAt,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.Status,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.SearchVector,
+		&i.ScrubbedAt,
+	)
+	return i, err
+}
+
+const softDeleteChirp = `-- name: SoftDeleteChirp :exec
+UPDATE chirps SET deleted_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) SoftDeleteChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, softDeleteChirp, id)
+	return err
+}
+
+const softDeleteRechirpsOf = `-- name: SoftDeleteRechirpsOf :exec
+UPDATE chirps SET deleted_at = NOW()
+WHERE rechirp_of = $1
+AND deleted_at IS NULL
+`
+
+func (q *Queries) SoftDeleteRechirpsOf(ctx context.Context, rechirpOf uuid.NullUUID) error {
+	_, err := q.db.ExecContext(ctx, softDeleteRechirpsOf, rechirpOf)
+	return err
+}
+
+const updateChirpBody = `-- name: UpdateChirpBody :one
+UPDATE chirps SET body = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, body, user_id, in_reply_to, deleted_at, rechirp_of, quote_of, status, publish_at, visibility, content_warning, search_vector, scrubbed_at
+`
+
+type UpdateChirpBodyParams struct {
+	ID   uuid.UUID
+	Body string
+}
+
+func (q *Queries) UpdateChirpBody(ctx context.Context, arg UpdateChirpBodyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpBody, arg.ID, arg.Body)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.InReplyTo,
+		&i.DeletedAt,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.Status,
+		&i.PublishAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.SearchVector,
+		&i.ScrubbedAt,
+	)
+	return i, err
+}
@@ -11,12 +11,167 @@ import (
 	"github.com/google/uuid"
 )
 
+type Block struct {
+	UserID    uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type Bookmark struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
 type Chirp struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Body           string
+	UserID         uuid.UUID
+	InReplyTo      uuid.NullUUID
+	DeletedAt      sql.NullTime
+	RechirpOf      uuid.NullUUID
+	QuoteOf        uuid.NullUUID
+	Status         string
+	PublishAt      sql.NullTime
+	Visibility     string
+	ContentWarning string
+	SearchVector   interface{}
+	ScrubbedAt     sql.NullTime
+}
+
+type ChirpHashtag struct {
+	ChirpID   uuid.UUID
+	HashtagID uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpRevision struct {
+	ID         uuid.UUID
+	ChirpID    uuid.UUID
+	Body       string
+	CreatedAt  time.Time
+	ReplacedAt time.Time
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type Hashtag struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	Tag       string
+}
+
+type Like struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type MediaVariant struct {
+	MediaID     uuid.UUID
+	Name        string
+	Width       int32
+	Height      int32
+	ContentType string
+	StorageKey  string
+}
+
+type Medium struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UserID      uuid.UUID
+	ContentType string
+	SizeBytes   int64
+	StorageKey  string
+	ChirpID     uuid.NullUUID
+	Position    int32
+	Status      string
+	Width       sql.NullInt32
+	Height      sql.NullInt32
+	Blurhash    sql.NullString
+}
+
+type Mention struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	Handle    string
+	CreatedAt time.Time
+}
+
+type ModerationDecision struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	ModeratorID uuid.NullUUID
+	ReportID    uuid.NullUUID
+	Action      string
+	UserID      uuid.NullUUID
+	ChirpID     uuid.NullUUID
+	Note        string
+}
+
+type ModerationFlag struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	ChirpID   uuid.UUID
+	RuleID    uuid.NullUUID
+	Matched   string
+}
+
+type ModerationRule struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	Body      string
+	Kind      string
+	Pattern   string
+	Action    string
+}
+
+type Mute struct {
+	UserID    uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type MutedWord struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
 	UserID    uuid.UUID
+	Phrase    string
+	WholeWord bool
+	Pattern   string
+	ExpiresAt sql.NullTime
+}
+
+type PinnedChirp struct {
+	UserID   uuid.UUID
+	ChirpID  uuid.UUID
+	PinnedAt time.Time
+}
+
+type Poll struct {
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	Results   string
+}
+
+type PollOption struct {
+	ChirpID  uuid.UUID
+	Position int32
+	Text     string
+}
+
+type PollVote struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	Position  int32
+	CreatedAt time.Time
 }
 
 type RefreshToken struct {
@@ -28,6 +183,28 @@ type RefreshToken struct {
 	RevokedAt sql.NullTime
 }
 
+type Report struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	ReporterID uuid.UUID
+	UserID     uuid.UUID
+	ChirpID    uuid.NullUUID
+	Reason     string
+	Details    string
+	Status     string
+	ClaimedBy  uuid.NullUUID
+	Resolution sql.NullString
+}
+
+type TrendingHashtag struct {
+	HashtagID     uuid.UUID
+	RecentCount   int64
+	PreviousCount int64
+	Score         float64
+	ComputedAt    time.Time
+}
+
 type User struct {
 	ID             uuid.UUID
 	CreatedAt      time.Time
@@ -35,4 +212,14 @@ type User struct {
 	Email          string
 	HashedPassword string
 	IsChirpyRed    bool
+	Handle         sql.NullString
+	IsModerator    bool
+	SuspendedAt    sql.NullTime
+	SuspendedUntil sql.NullTime
+	ShadowbannedAt sql.NullTime
+	DisplayName    string
+	Bio            string
+	Website        string
+	Location       string
+	AvatarMediaID  uuid.NullUUID
 }
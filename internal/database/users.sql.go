@@ -7,25 +7,28 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle)
 VALUES (
-    gen_random_uuid(), NOW(), NOW(), $1, $2
+    gen_random_uuid(), NOW(), NOW(), $1, $2, $3
 )
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, is_moderator, suspended_at, suspended_until, shadowbanned_at, display_name, bio, website, location, avatar_media_id
 `
 
 type CreateUserParams struct {
 	Email          string
 	HashedPassword string
+	Handle         sql.NullString
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword)
+	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword, arg.Handle)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -34,6 +37,16 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.IsModerator,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.ShadowbannedAt,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Website,
+		&i.Location,
+		&i.AvatarMediaID,
 	)
 	return i, err
 }
@@ -47,8 +60,37 @@ func (q *Queries) DeleteAllUsers(ctx context.Context) error {
 	return err
 }
 
+const getUser = `-- name: GetUser :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, is_moderator, suspended_at, suspended_until, shadowbanned_at, display_name, bio, website, location, avatar_media_id FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.IsModerator,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.ShadowbannedAt,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Website,
+		&i.Location,
+		&i.AvatarMediaID,
+	)
+	return i, err
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, is_moderator, suspended_at, suspended_until, shadowbanned_at, display_name, bio, website, location, avatar_media_id FROM users
 WHERE email = $1
 `
 
@@ -62,14 +104,98 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.IsModerator,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.ShadowbannedAt,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Website,
+		&i.Location,
+		&i.AvatarMediaID,
+	)
+	return i, err
+}
+
+const getUserByHandle = `-- name: GetUserByHandle :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, is_moderator, suspended_at, suspended_until, shadowbanned_at, display_name, bio, website, location, avatar_media_id FROM users
+WHERE LOWER(handle) = LOWER($1)
+`
+
+func (q *Queries) GetUserByHandle(ctx context.Context, handle string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByHandle, handle)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.IsModerator,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.ShadowbannedAt,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Website,
+		&i.Location,
+		&i.AvatarMediaID,
 	)
 	return i, err
 }
 
+const getUsersByHandles = `-- name: GetUsersByHandles :many
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, is_moderator, suspended_at, suspended_until, shadowbanned_at, display_name, bio, website, location, avatar_media_id FROM users
+WHERE LOWER(handle) = ANY($1::text[])
+`
+
+func (q *Queries) GetUsersByHandles(ctx context.Context, handles []string) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersByHandles, pq.Array(handles))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.IsChirpyRed,
+			&i.Handle,
+			&i.IsModerator,
+			&i.SuspendedAt,
+			&i.SuspendedUntil,
+			&i.ShadowbannedAt,
+			&i.DisplayName,
+			&i.Bio,
+			&i.Website,
+			&i.Location,
+			&i.AvatarMediaID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const grantPremium = `-- name: GrantPremium :one
 UPDATE users SET is_chirpy_red = true
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, is_moderator, suspended_at, suspended_until, shadowbanned_at, display_name, bio, website, location, avatar_media_id
 `
 
 func (q *Queries) GrantPremium(ctx context.Context, id uuid.UUID) (User, error) {
@@ -82,14 +208,133 @@ func (q *Queries) GrantPremium(ctx context.Context, id uuid.UUID) (User, error)
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.IsModerator,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.ShadowbannedAt,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Website,
+		&i.Location,
+		&i.AvatarMediaID,
+	)
+	return i, err
+}
+
+const isUserVisibleTo = `-- name: IsUserVisibleTo :one
+SELECT author_visible_to($1::uuid, $2::uuid)
+`
+
+type IsUserVisibleToParams struct {
+	UserID   uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) IsUserVisibleTo(ctx context.Context, arg IsUserVisibleToParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isUserVisibleTo, arg.UserID, arg.ViewerID)
+	var author_visible_to bool
+	err := row.Scan(&author_visible_to)
+	return author_visible_to, err
+}
+
+const liftExpiredSuspensions = `-- name: LiftExpiredSuspensions :execrows
+UPDATE users SET suspended_at = NULL, suspended_until = NULL, updated_at = NOW()
+WHERE suspended_until <= NOW()
+`
+
+func (q *Queries) LiftExpiredSuspensions(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, liftExpiredSuspensions)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const liftShadowban = `-- name: LiftShadowban :exec
+UPDATE users SET shadowbanned_at = NULL, updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) LiftShadowban(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, liftShadowban, id)
+	return err
+}
+
+const liftSuspension = `-- name: LiftSuspension :exec
+UPDATE users SET suspended_at = NULL, suspended_until = NULL, updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) LiftSuspension(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, liftSuspension, id)
+	return err
+}
+
+const setUserHandle = `-- name: SetUserHandle :one
+UPDATE users SET handle = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, is_moderator, suspended_at, suspended_until, shadowbanned_at, display_name, bio, website, location, avatar_media_id
+`
+
+type SetUserHandleParams struct {
+	ID     uuid.UUID
+	Handle sql.NullString
+}
+
+func (q *Queries) SetUserHandle(ctx context.Context, arg SetUserHandleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserHandle, arg.ID, arg.Handle)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.IsModerator,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.ShadowbannedAt,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Website,
+		&i.Location,
+		&i.AvatarMediaID,
 	)
 	return i, err
 }
 
+const shadowbanUser = `-- name: ShadowbanUser :exec
+UPDATE users SET shadowbanned_at = COALESCE(shadowbanned_at, NOW()), updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) ShadowbanUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, shadowbanUser, id)
+	return err
+}
+
+const suspendUser = `-- name: SuspendUser :exec
+UPDATE users SET suspended_at = NOW(), suspended_until = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SuspendUserParams struct {
+	ID             uuid.UUID
+	SuspendedUntil sql.NullTime
+}
+
+func (q *Queries) SuspendUser(ctx context.Context, arg SuspendUserParams) error {
+	_, err := q.db.ExecContext(ctx, suspendUser, arg.ID, arg.SuspendedUntil)
+	return err
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users SET email = $2, hashed_password = $3, updated_at = NOW()
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, is_moderator, suspended_at, suspended_until, shadowbanned_at, display_name, bio, website, location, avatar_media_id
 `
 
 type UpdateUserParams struct {
@@ -108,6 +353,73 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.IsModerator,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.ShadowbannedAt,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Website,
+		&i.Location,
+		&i.AvatarMediaID,
+	)
+	return i, err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE users SET
+    handle = COALESCE($1, handle),
+    display_name = COALESCE($2, display_name),
+    bio = COALESCE($3, bio),
+    website = COALESCE($4, website),
+    location = COALESCE($5, location),
+    avatar_media_id = CASE WHEN $6::boolean THEN $7::uuid ELSE avatar_media_id END,
+    updated_at = NOW()
+WHERE id = $8
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, is_moderator, suspended_at, suspended_until, shadowbanned_at, display_name, bio, website, location, avatar_media_id
+`
+
+type UpdateUserProfileParams struct {
+	Handle        sql.NullString
+	DisplayName   sql.NullString
+	Bio           sql.NullString
+	Website       sql.NullString
+	Location      sql.NullString
+	SetAvatar     bool
+	AvatarMediaID uuid.NullUUID
+	ID            uuid.UUID
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserProfile,
+		arg.Handle,
+		arg.DisplayName,
+		arg.Bio,
+		arg.Website,
+		arg.Location,
+		arg.SetAvatar,
+		arg.AvatarMediaID,
+		arg.ID,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.IsModerator,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.ShadowbannedAt,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Website,
+		&i.Location,
+		&i.AvatarMediaID,
 	)
 	return i, err
 }
@@ -0,0 +1,93 @@
+package entities
+
+import (
+	"strings"
+	"unicode"
+)
+
+type Kind string
+
+const (
+	KindHashtag Kind = "hashtag"
+	KindMention Kind = "mention"
+)
+
+const (
+	maxEntityLength = 100
+	minHandleLength = 3
+	maxHandleLength = 15
+)
+
+// Entity is a piece of chirp text with special meaning. Start and End are offsets in runes
+// (Unicode code points), End being exclusive, and cover the sigil as well as the text.
+type Entity struct {
+	Kind  Kind
+	Text  string
+	Start int
+	End   int
+}
+
+// ParseHashtags finds #tags in body. A tag must start at a word boundary, consist of letters,
+// digits and underscores, and contain at least one letter so "#1" is not a tag.
+func ParseHashtags(body string) []Entity {
+	return parse(body, '#', KindHashtag, isTagRune, true, maxEntityLength)
+}
+
+// ParseMentions finds @handles in body. Mentions follow the same rules as handles, and an @
+// preceded by a word character (as in an email address) is not a mention.
+func ParseMentions(body string) []Entity {
+	return parse(body, '@', KindMention, isHandleRune, false, maxHandleLength)
+}
+
+// IsValidHandle reports whether handle can be registered and mentioned.
+func IsValidHandle(handle string) bool {
+	if len(handle) < minHandleLength || len(handle) > maxHandleLength {
+		return false
+	}
+	for _, r := range handle {
+		if !isHandleRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeTag returns the form hashtags are stored and looked up by.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(tag, "#"))
+}
+
+func parse(body string, sigil rune, kind Kind, isEntityRune func(rune) bool, needsLetter bool, maxLength int) []Entity {
+	found := []Entity{}
+	runes := []rune(body)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != sigil || (i > 0 && isTagRune(runes[i-1])) {
+			continue
+		}
+		end := i + 1
+		hasLetter := false
+		for end < len(runes) && isEntityRune(runes[end]) {
+			hasLetter = hasLetter || unicode.IsLetter(runes[end])
+			end++
+		}
+		length := end - i - 1
+		if length > 0 && (hasLetter || !needsLetter) && length <= maxLength {
+			found = append(found, Entity{
+				Kind:  kind,
+				Text:  string(runes[i+1 : end]),
+				Start: i,
+				End:   end,
+			})
+		}
+		i = end - 1
+	}
+	return found
+}
+
+func isTagRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+func isHandleRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_'
+}
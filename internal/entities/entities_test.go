@@ -0,0 +1,125 @@
+package entities
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseHashtags(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []Entity
+	}{
+		{
+			name: "No hashtags",
+			body: "just a chirp",
+			want: []Entity{},
+		},
+		{
+			name: "Hashtags with punctuation",
+			body: "#Go is fun, #golang!",
+			want: []Entity{
+				{Kind: KindHashtag, Text: "Go", Start: 0, End: 3},
+				{Kind: KindHashtag, Text: "golang", Start: 12, End: 19},
+			},
+		},
+		{
+			name: "Offsets are in runes",
+			body: "čau #svět",
+			want: []Entity{
+				{Kind: KindHashtag, Text: "svět", Start: 4, End: 9},
+			},
+		},
+		{
+			name: "Numbers and mid-word hashes are not tags",
+			body: "issue #1 and c#sharp",
+			want: []Entity{},
+		},
+		{
+			name: "Double hash",
+			body: "##go",
+			want: []Entity{
+				{Kind: KindHashtag, Text: "go", Start: 1, End: 4},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseHashtags(tt.body)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseHashtags() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []Entity
+	}{
+		{
+			name: "Mentions",
+			body: "hi @alice and @Bob_2!",
+			want: []Entity{
+				{Kind: KindMention, Text: "alice", Start: 3, End: 9},
+				{Kind: KindMention, Text: "Bob_2", Start: 14, End: 20},
+			},
+		},
+		{
+			name: "Email address is not a mention",
+			body: "mail me at me@example.com",
+			want: []Entity{},
+		},
+		{
+			name: "Lone at sign",
+			body: "meet @ noon",
+			want: []Entity{},
+		},
+		{
+			name: "Too long to be a handle",
+			body: "@abcdefghijklmnopq",
+			want: []Entity{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseMentions(tt.body)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseMentions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsValidHandle(t *testing.T) {
+	tests := []struct {
+		handle string
+		want   bool
+	}{
+		{handle: "alice", want: true},
+		{handle: "Bob_2", want: true},
+		{handle: "al", want: false},
+		{handle: "abcdefghijklmnop", want: false},
+		{handle: "bad-handle", want: false},
+		{handle: "čau", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.handle, func(t *testing.T) {
+			if got := IsValidHandle(tt.handle); got != tt.want {
+				t.Errorf("IsValidHandle(%q) = %v, want %v", tt.handle, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeTag(t *testing.T) {
+	if got := NormalizeTag("#GoLang"); got != "golang" {
+		t.Errorf("NormalizeTag() = %v, want %v", got, "golang")
+	}
+}
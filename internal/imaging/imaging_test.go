@@ -0,0 +1,225 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func solidImage(width, height int, c color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// jpegWithExif encodes a small JPEG and inserts an EXIF block with an orientation and a
+// fake GPS payload right after the SOI marker.
+func jpegWithExif(t *testing.T, orientation int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	err := jpeg.Encode(&buf, solidImage(8, 4, color.White), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exif := minimalExif(orientation)
+	exif = append(exif, []byte("GPS 50.0755N 14.4378E")...)
+	binary.BigEndian.PutUint16(exif[2:], uint16(len(exif)-2))
+
+	data := buf.Bytes()
+	out := append([]byte{}, data[:2]...)
+	out = append(out, exif...)
+	out = append(out, 0xFF, 0xFE, 0x00, 0x09)
+	out = append(out, []byte("comment")...)
+	return append(out, data[2:]...)
+}
+
+func pngChunk(kind string, data []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, kind...)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+func TestStripMetadataJPEG(t *testing.T) {
+	data := jpegWithExif(t, 6)
+	if Orientation(data) != 6 {
+		t.Fatalf("Orientation() of input = %d, want 6", Orientation(data))
+	}
+
+	stripped, err := StripMetadata(data, "image/jpeg")
+	if err != nil {
+		t.Fatalf("StripMetadata() error = %v", err)
+	}
+	if bytes.Contains(stripped, []byte("GPS")) || bytes.Contains(stripped, []byte("comment")) {
+		t.Errorf("StripMetadata() kept metadata")
+	}
+	if got := Orientation(stripped); got != 6 {
+		t.Errorf("Orientation() after StripMetadata() = %d, want 6", got)
+	}
+	_, err = Decode(stripped)
+	if err != nil {
+		t.Errorf("Decode() after StripMetadata() error = %v", err)
+	}
+}
+
+func TestStripMetadataJPEGUpright(t *testing.T) {
+	stripped, err := StripMetadata(jpegWithExif(t, 1), "image/jpeg")
+	if err != nil {
+		t.Fatalf("StripMetadata() error = %v", err)
+	}
+	if bytes.Contains(stripped, jpegExifHeader) {
+		t.Errorf("StripMetadata() kept an EXIF block for an upright image")
+	}
+}
+
+func TestStripMetadataPNG(t *testing.T) {
+	var buf bytes.Buffer
+	err := png.Encode(&buf, solidImage(4, 4, color.Black))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	// The IHDR chunk is always 25 bytes long.
+	ihdrEnd := len(pngSignature) + 25
+	withText := append([]byte{}, data[:ihdrEnd]...)
+	withText = append(withText, pngChunk("tEXt", []byte("Location\x00secret place"))...)
+	withText = append(withText, pngChunk("eXIf", []byte("MM\x00*secret"))...)
+	withText = append(withText, data[ihdrEnd:]...)
+
+	stripped, err := StripMetadata(withText, "image/png")
+	if err != nil {
+		t.Fatalf("StripMetadata() error = %v", err)
+	}
+	if !bytes.Equal(stripped, data) {
+		t.Errorf("StripMetadata() = %d bytes, want the original %d", len(stripped), len(data))
+	}
+}
+
+func TestStripMetadataMalformed(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        []byte
+		contentType string
+	}{
+		{"Truncated JPEG", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x10}, "image/jpeg"},
+		{"Not a PNG", []byte("GIF89a"), "image/png"},
+		{"Truncated WebP chunk", []byte("RIFF\x10\x00\x00\x00WEBPEXIF\xff\x00\x00\x00"), "image/webp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := StripMetadata(tt.data, tt.contentType)
+			if err != ErrMalformed {
+				t.Errorf("StripMetadata() error = %v, want ErrMalformed", err)
+			}
+		})
+	}
+}
+
+func TestStripMetadataWebP(t *testing.T) {
+	data := []byte("RIFF\x00\x00\x00\x00WEBP")
+	data = append(data, "VP8X\x0a\x00\x00\x00\x0c\x00\x00\x00\x00\x00\x00\x00\x00\x00"...)
+	data = append(data, "EXIF\x03\x00\x00\x00GPS\x00"...)
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(data)-8))
+
+	stripped, err := StripMetadata(data, "image/webp")
+	if err != nil {
+		t.Fatalf("StripMetadata() error = %v", err)
+	}
+	if bytes.Contains(stripped, []byte("GPS")) {
+		t.Errorf("StripMetadata() kept the EXIF chunk")
+	}
+	if flags := stripped[20]; flags != 0 {
+		t.Errorf("VP8X flags = %#x, want 0", flags)
+	}
+	if size := binary.LittleEndian.Uint32(stripped[4:]); int(size) != len(stripped)-8 {
+		t.Errorf("RIFF size = %d, want %d", size, len(stripped)-8)
+	}
+}
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		maxSize       int
+		wantW, wantH  int
+	}{
+		{"Landscape", 400, 200, 100, 100, 50},
+		{"Portrait", 200, 400, 100, 50, 100},
+		{"Already fits", 80, 60, 100, 80, 60},
+		{"Very thin", 1000, 2, 100, 100, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Fit(solidImage(tt.width, tt.height, color.White), tt.maxSize).Bounds()
+			if got.Dx() != tt.wantW || got.Dy() != tt.wantH {
+				t.Errorf("Fit() = %dx%d, want %dx%d", got.Dx(), got.Dy(), tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestOrient(t *testing.T) {
+	red := color.NRGBA{R: 255, A: 255}
+	blue := color.NRGBA{B: 255, A: 255}
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, red)
+	img.Set(1, 0, blue)
+
+	tests := []struct {
+		orientation int
+		want        [][]color.NRGBA
+	}{
+		{1, [][]color.NRGBA{{red, blue}}},
+		{2, [][]color.NRGBA{{blue, red}}},
+		{3, [][]color.NRGBA{{blue, red}}},
+		{6, [][]color.NRGBA{{red}, {blue}}},
+		{8, [][]color.NRGBA{{blue}, {red}}},
+	}
+	for _, tt := range tests {
+		got := Orient(img, tt.orientation)
+		if got.Bounds().Dy() != len(tt.want) || got.Bounds().Dx() != len(tt.want[0]) {
+			t.Errorf("Orient(%d) size = %v", tt.orientation, got.Bounds())
+			continue
+		}
+		for y, row := range tt.want {
+			for x, want := range row {
+				if c := color.NRGBAModel.Convert(got.At(x, y)); c != want {
+					t.Errorf("Orient(%d) at (%d, %d) = %v, want %v", tt.orientation, x, y, c, want)
+				}
+			}
+		}
+	}
+}
+
+func TestBlurhash(t *testing.T) {
+	tests := []struct {
+		name    string
+		img     image.Image
+		wantAvg string
+	}{
+		{"Black", solidImage(32, 32, color.Black), "0000"},
+		{"White", solidImage(32, 32, color.White), "TSUA"},
+		{"Red", solidImage(32, 32, color.NRGBA{R: 255, A: 255}), "TI:j"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Blurhash(tt.img, 4, 3)
+			if len(got) != 28 {
+				t.Fatalf("Blurhash() = %q, want 28 characters", got)
+			}
+			// "L" encodes 4x3 components, and characters 2 to 6 the average colour.
+			if got[0] != 'L' || got[2:6] != tt.wantAvg {
+				t.Errorf("Blurhash() = %q, want L?%s...", got, tt.wantAvg)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var ErrMalformed = errors.New("malformed image")
+
+var (
+	jpegExifHeader = []byte("Exif\x00\x00")
+	jpegICCHeader  = []byte("ICC_PROFILE\x00")
+	pngSignature   = []byte("\x89PNG\r\n\x1a\n")
+)
+
+const orientationTag = 0x0112
+
+// StripMetadata removes EXIF, XMP, IPTC and comment data, which can include the GPS position
+// a photo was taken at, without re-encoding the image. The only thing kept from a JPEG's EXIF
+// block is its orientation, since dropping it would show the photo sideways. GIFs can't carry
+// EXIF and are returned as is.
+func StripMetadata(data []byte, contentType string) ([]byte, error) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEG(data)
+	case "image/png":
+		return stripPNG(data)
+	case "image/webp":
+		return stripWebP(data)
+	default:
+		return data, nil
+	}
+}
+
+// Orientation returns the EXIF orientation of a JPEG, from 1 (upright) to 8. Images without
+// one are upright.
+func Orientation(data []byte) int {
+	orientation := 1
+	walkJPEG(data, func(marker byte, segment []byte) bool {
+		if marker == 0xE1 && bytes.HasPrefix(segment, jpegExifHeader) {
+			orientation = exifOrientation(segment[len(jpegExifHeader):])
+			return false
+		}
+		return true
+	})
+	return orientation
+}
+
+func stripJPEG(data []byte) ([]byte, error) {
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write([]byte{0xFF, 0xD8})
+	orientation := 1
+	wroteExif := false
+	writeExif := func() {
+		if orientation != 1 && !wroteExif {
+			out.Write(minimalExif(orientation))
+			wroteExif = true
+		}
+	}
+
+	rest, err := walkJPEG(data, func(marker byte, segment []byte) bool {
+		switch {
+		case marker == 0xE1:
+			if bytes.HasPrefix(segment, jpegExifHeader) {
+				orientation = exifOrientation(segment[len(jpegExifHeader):])
+			}
+			return true
+		case marker == 0xE2 && !bytes.HasPrefix(segment, jpegICCHeader):
+			return true
+		case marker >= 0xE3 && marker <= 0xEF && marker != 0xEE, marker == 0xFE:
+			return true
+		}
+		// The orientation goes right after the JFIF header, or first if there is none.
+		if marker != 0xE0 {
+			writeExif()
+		}
+		out.Write([]byte{0xFF, marker})
+		binary.Write(out, binary.BigEndian, uint16(len(segment)+2))
+		out.Write(segment)
+		if marker == 0xE0 {
+			writeExif()
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	writeExif()
+	out.Write(rest)
+	return out.Bytes(), nil
+}
+
+// walkJPEG calls fn with every marker segment before the image data, until fn returns false.
+// It returns the remaining bytes, starting at the start-of-scan marker.
+func walkJPEG(data []byte, fn func(marker byte, segment []byte) bool) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, ErrMalformed
+	}
+	pos := 2
+	for {
+		if pos+2 > len(data) || data[pos] != 0xFF {
+			return nil, ErrMalformed
+		}
+		marker := data[pos+1]
+		if marker == 0xFF {
+			// Fill byte before the actual marker.
+			pos++
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 {
+			return data[pos:], nil
+		}
+		if pos+4 > len(data) {
+			return nil, ErrMalformed
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if length < 2 || pos+2+length > len(data) {
+			return nil, ErrMalformed
+		}
+		if !fn(marker, data[pos+4:pos+2+length]) {
+			return data[pos:], nil
+		}
+		pos += 2 + length
+	}
+}
+
+// exifOrientation reads the orientation tag from the first IFD of a TIFF structure.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) != orientationTag {
+			continue
+		}
+		value := int(order.Uint16(tiff[entry+8:]))
+		if value < 1 || value > 8 {
+			return 1
+		}
+		return value
+	}
+	return 1
+}
+
+// minimalExif builds an APP1 segment holding nothing but an orientation tag.
+func minimalExif(orientation int) []byte {
+	tiff := []byte{
+		'M', 'M', 0x00, 0x2A, 0x00, 0x00, 0x00, 0x08, // header, first IFD at offset 8
+		0x00, 0x01, // one entry
+		0x01, 0x12, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, // orientation, SHORT, count 1
+		0x00, byte(orientation), 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, // no next IFD
+	}
+	segment := []byte{0xFF, 0xE1, 0x00, 0x00}
+	binary.BigEndian.PutUint16(segment[2:], uint16(2+len(jpegExifHeader)+len(tiff)))
+	segment = append(segment, jpegExifHeader...)
+	return append(segment, tiff...)
+}
+
+// pngMetadataChunks are the ancillary chunks that describe the image rather than its pixels.
+var pngMetadataChunks = map[string]bool{
+	"eXIf": true,
+	"iTXt": true,
+	"tEXt": true,
+	"tIME": true,
+	"zTXt": true,
+}
+
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, ErrMalformed
+	}
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(pngSignature)
+	pos := len(pngSignature)
+	for pos < len(data) {
+		if pos+8 > len(data) {
+			return nil, ErrMalformed
+		}
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, ErrMalformed
+		}
+		if !pngMetadataChunks[string(data[pos+4:pos+8])] {
+			out.Write(data[pos:end])
+		}
+		pos = end
+	}
+	return out.Bytes(), nil
+}
+
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, ErrMalformed
+	}
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:12])
+	vp8xFlags := -1
+	pos := 12
+	for pos < len(data) {
+		if pos+8 > len(data) {
+			return nil, ErrMalformed
+		}
+		fourCC := string(data[pos : pos+4])
+		length := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		// Chunks are padded to an even length.
+		end := pos + 8 + length + length%2
+		if length < 0 || end > len(data) {
+			return nil, ErrMalformed
+		}
+		if fourCC != "EXIF" && fourCC != "XMP " {
+			if fourCC == "VP8X" && length >= 1 {
+				vp8xFlags = out.Len() + 8
+			}
+			out.Write(data[pos:end])
+		}
+		pos = end
+	}
+
+	stripped := out.Bytes()
+	if vp8xFlags >= 0 {
+		// Clear the EXIF and XMP presence flags.
+		stripped[vp8xFlags] &^= 0x08 | 0x04
+	}
+	binary.LittleEndian.PutUint32(stripped[4:], uint32(len(stripped)-8))
+	return stripped, nil
+}
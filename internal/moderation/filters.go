@@ -0,0 +1,166 @@
+package moderation
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/docherak/bd-chirpy/internal/chirptext"
+	"golang.org/x/text/unicode/norm"
+)
+
+// WordFilter matches whole words. Words are compared in a folded form, so "K3rfüffle" and a
+// "kerfuffle" spelt with Cyrillic letters both match "kerfuffle". Links are left alone.
+type WordFilter struct {
+	rules map[string]Rule
+}
+
+func NewWordFilter(rules []Rule) WordFilter {
+	filter := WordFilter{rules: map[string]Rule{}}
+	for _, rule := range rules {
+		filter.rules[Fold(rule.Pattern)] = rule
+	}
+	return filter
+}
+
+func (f WordFilter) Apply(text string) (string, []Match) {
+	matches := []Match{}
+	if len(f.rules) == 0 {
+		return text, matches
+	}
+	links := chirptext.Links(text)
+	toMask := []chirptext.Token{}
+	for _, word := range Words(text) {
+		rule, ok := f.rules[Fold(word.Text)]
+		if !ok || chirptext.Inside(word, links) {
+			continue
+		}
+		matches = append(matches, Match{Rule: rule, Text: word.Text})
+		if rule.Action == ActionMask {
+			toMask = append(toMask, word)
+		}
+	}
+	return chirptext.Mask(text, toMask), matches
+}
+
+// RegexFilter matches case-insensitive regular expressions anywhere in the text.
+type RegexFilter struct {
+	rules   []Rule
+	regexes []*regexp.Regexp
+}
+
+func NewRegexFilter(rules []Rule) (RegexFilter, error) {
+	filter := RegexFilter{}
+	for _, rule := range rules {
+		re, err := regexp.Compile("(?i)" + rule.Pattern)
+		if err != nil {
+			return RegexFilter{}, err
+		}
+		filter.rules = append(filter.rules, rule)
+		filter.regexes = append(filter.regexes, re)
+	}
+	return filter, nil
+}
+
+func (f RegexFilter) Apply(text string) (string, []Match) {
+	matches := []Match{}
+	for i, re := range f.regexes {
+		rule := f.rules[i]
+		found := re.FindAllStringIndex(text, -1)
+		toMask := []chirptext.Token{}
+		for _, loc := range found {
+			if loc[0] == loc[1] {
+				continue
+			}
+			span := chirptext.Token{Text: text[loc[0]:loc[1]], Start: loc[0], End: loc[1]}
+			matches = append(matches, Match{Rule: rule, Text: span.Text})
+			toMask = append(toMask, span)
+		}
+		if rule.Action == ActionMask {
+			text = chirptext.Mask(text, toMask)
+		}
+	}
+	return text, matches
+}
+
+// Words splits text like chirptext.Words, but keeps the symbols people type in place of
+// letters, so "f0rn@x" stays one word.
+func Words(text string) []chirptext.Token {
+	words := []chirptext.Token{}
+	start := -1
+	for i, r := range text {
+		if isWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			words = append(words, chirptext.Token{Text: text[start:i], Start: start, End: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		words = append(words, chirptext.Token{Text: text[start:], Start: start, End: len(text)})
+	}
+	// A word made of symbols only, like "$$$" or a trailing "!", is punctuation after all.
+	trimmed := words[:0]
+	for _, word := range words {
+		word = trimSymbols(word)
+		if word.Start < word.End {
+			trimmed = append(trimmed, word)
+		}
+	}
+	return trimmed
+}
+
+// Fold reduces a word to the form rules are matched in: lower case, without accents, with
+// look-alike letters from other scripts and leetspeak digits and symbols turned into the
+// Latin letters they stand for.
+func Fold(word string) string {
+	var b strings.Builder
+	for _, r := range norm.NFKD.String(strings.ToLower(word)) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if latin, ok := lookalikes[r]; ok {
+			r = latin
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+var lookalikes = map[rune]rune{
+	// Leetspeak.
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b',
+	'@': 'a', '$': 's', '!': 'i', '|': 'l', '+': 't',
+	// Cyrillic.
+	'а': 'a', 'в': 'b', 'е': 'e', 'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p',
+	'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'і': 'i', 'ј': 'j', 'ѕ': 's',
+	// Greek.
+	'α': 'a', 'β': 'b', 'ε': 'e', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p',
+	'τ': 't', 'υ': 'u', 'χ': 'x',
+}
+
+func isWordRune(r rune) bool {
+	if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || r == '_' || r == '\'' {
+		return true
+	}
+	_, ok := lookalikes[r]
+	return ok
+}
+
+// trimSymbols drops leading and trailing leetspeak symbols that are more likely punctuation,
+// such as the "!" in "fornax!", keeping them inside a word.
+func trimSymbols(word chirptext.Token) chirptext.Token {
+	isSymbol := func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r) && r != '_'
+	}
+	trimmedStart := strings.TrimLeftFunc(word.Text, isSymbol)
+	word.Start += len(word.Text) - len(trimmedStart)
+	trimmed := strings.TrimRightFunc(trimmedStart, isSymbol)
+	word.End = word.Start + len(trimmed)
+	word.Text = trimmed
+	return word
+}
@@ -0,0 +1,169 @@
+package moderation
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+type Action string
+
+const (
+	// ActionMask replaces the matched text with asterisks.
+	ActionMask Action = "mask"
+	// ActionReject refuses the text altogether.
+	ActionReject Action = "reject"
+	// ActionFlag lets the text through unchanged but marks it for a moderator to review.
+	ActionFlag Action = "flag"
+)
+
+type Kind string
+
+const (
+	// KindWord matches a whole word, seeing through look-alike letters and leetspeak.
+	KindWord Kind = "word"
+	// KindRegex matches a case-insensitive regular expression.
+	KindRegex Kind = "regex"
+)
+
+// Rule is one entry of the moderation lists.
+type Rule struct {
+	ID      uuid.UUID
+	Kind    Kind
+	Pattern string
+	Action  Action
+}
+
+// Match is a piece of text that a rule caught.
+type Match struct {
+	Rule Rule
+	Text string
+}
+
+// Result is what's left of a text after the filters ran, and what they found in it.
+type Result struct {
+	Text    string
+	Matches []Match
+}
+
+// Rejected reports whether any rule refuses the text.
+func (r Result) Rejected() bool {
+	return r.has(ActionReject)
+}
+
+// Flags returns the matches that should be reviewed by a moderator.
+func (r Result) Flags() []Match {
+	flags := []Match{}
+	for _, match := range r.Matches {
+		if match.Rule.Action == ActionFlag {
+			flags = append(flags, match)
+		}
+	}
+	return flags
+}
+
+func (r Result) has(action Action) bool {
+	for _, match := range r.Matches {
+		if match.Rule.Action == action {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter checks text against its rules. It returns the text with anything masked and what
+// it matched.
+type Filter interface {
+	Apply(text string) (string, []Match)
+}
+
+// Pipeline runs filters in order, each one seeing the previous one's output.
+type Pipeline []Filter
+
+func (p Pipeline) Run(text string) Result {
+	result := Result{Text: text, Matches: []Match{}}
+	for _, filter := range p {
+		var matches []Match
+		result.Text, matches = filter.Apply(result.Text)
+		result.Matches = append(result.Matches, matches...)
+	}
+	return result
+}
+
+// NewPipeline builds the standard chain from rules: the word list, then regular expressions.
+func NewPipeline(rules []Rule) (Pipeline, error) {
+	words := []Rule{}
+	regexes := []Rule{}
+	for _, rule := range rules {
+		err := ValidateRule(rule)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", rule.Pattern, err)
+		}
+		if rule.Kind == KindWord {
+			words = append(words, rule)
+		} else {
+			regexes = append(regexes, rule)
+		}
+	}
+
+	regexFilter, err := NewRegexFilter(regexes)
+	if err != nil {
+		return nil, err
+	}
+	return Pipeline{NewWordFilter(words), regexFilter}, nil
+}
+
+// ValidateRule checks that a rule can be used in a pipeline.
+func ValidateRule(rule Rule) error {
+	switch rule.Action {
+	case ActionMask, ActionReject, ActionFlag:
+	default:
+		return errors.New("unknown action")
+	}
+	switch rule.Kind {
+	case KindWord:
+		if len(Words(rule.Pattern)) != 1 {
+			return errors.New("word rules must be a single word")
+		}
+	case KindRegex:
+		if rule.Pattern == "" {
+			return errors.New("empty regular expression")
+		}
+		_, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return err
+		}
+	default:
+		return errors.New("unknown kind")
+	}
+	return nil
+}
+
+// Moderator holds the pipeline currently in use. Reloading swaps it in one go, so texts
+// being checked at the time see either the old rules or the new ones.
+type Moderator struct {
+	pipeline atomic.Pointer[Pipeline]
+}
+
+func NewModerator() *Moderator {
+	m := &Moderator{}
+	m.pipeline.Store(&Pipeline{})
+	return m
+}
+
+// Load replaces the rules in use. If any rule is invalid, the old rules stay.
+func (m *Moderator) Load(rules []Rule) error {
+	pipeline, err := NewPipeline(rules)
+	if err != nil {
+		return err
+	}
+	m.pipeline.Store(&pipeline)
+	return nil
+}
+
+func (m *Moderator) Check(text string) Result {
+	return m.pipeline.Load().Run(text)
+}
@@ -0,0 +1,176 @@
+package moderation
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{word: "Kerfuffle", want: "kerfuffle"},
+		{word: "k3rfuffl3", want: "kerfuffle"},
+		{word: "f0rn@x", want: "fornax"},
+		{word: "Fórnäx", want: "fornax"},
+		// Cyrillic "о" and "а".
+		{word: "fоrnаx", want: "fornax"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			got := Fold(tt.word)
+			if got != tt.want {
+				t.Errorf("Fold(%q) = %q, want %q", tt.word, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	rules := []Rule{
+		{Kind: KindWord, Pattern: "kerfuffle", Action: ActionMask},
+		{Kind: KindWord, Pattern: "fornax", Action: ActionMask},
+		{Kind: KindWord, Pattern: "spam", Action: ActionFlag},
+		{Kind: KindWord, Pattern: "forbidden", Action: ActionReject},
+		{Kind: KindRegex, Pattern: `\d{3}-\d{4}`, Action: ActionMask},
+	}
+	pipeline, err := NewPipeline(rules)
+	if err != nil {
+		t.Fatalf("NewPipeline() error = %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		text         string
+		wantText     string
+		wantRejected bool
+		wantFlags    int
+	}{
+		{
+			name:     "Clean text",
+			text:     "a perfectly fine chirp",
+			wantText: "a perfectly fine chirp",
+		},
+		{
+			name:     "Punctuation is kept",
+			text:     "What a Kerfuffle! Or fornax, anyway",
+			wantText: "What a ****! Or ****, anyway",
+		},
+		{
+			name:     "Newlines and tabs separate words",
+			text:     "kerfuffle\nfornax\tok",
+			wantText: "****\n****\tok",
+		},
+		{
+			name:     "Only whole words",
+			text:     "kerfuffles",
+			wantText: "kerfuffles",
+		},
+		{
+			name:     "Leetspeak and look-alikes",
+			text:     "k3rfuffl3 and f0rn@x",
+			wantText: "**** and ****",
+		},
+		{
+			name:     "Links are left alone",
+			text:     "https://fornax.example fornax",
+			wantText: "https://fornax.example ****",
+		},
+		{
+			name:     "Regex",
+			text:     "call 555-1234",
+			wantText: "call ****",
+		},
+		{
+			name:      "Flagged text passes unchanged",
+			text:      "buy SPAM now",
+			wantText:  "buy SPAM now",
+			wantFlags: 1,
+		},
+		{
+			name:         "Rejected",
+			text:         "this is forbidden",
+			wantText:     "this is forbidden",
+			wantRejected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pipeline.Run(tt.text)
+			if got.Text != tt.wantText {
+				t.Errorf("Run().Text = %q, want %q", got.Text, tt.wantText)
+			}
+			if got.Rejected() != tt.wantRejected {
+				t.Errorf("Run().Rejected() = %v, want %v", got.Rejected(), tt.wantRejected)
+			}
+			if len(got.Flags()) != tt.wantFlags {
+				t.Errorf("Run().Flags() = %+v, want %d flags", got.Flags(), tt.wantFlags)
+			}
+		})
+	}
+}
+
+func TestValidateRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Rule
+		wantErr bool
+	}{
+		{
+			name: "Valid word",
+			rule: Rule{Kind: KindWord, Pattern: "fornax", Action: ActionMask},
+		},
+		{
+			name:    "Several words",
+			rule:    Rule{Kind: KindWord, Pattern: "two words", Action: ActionMask},
+			wantErr: true,
+		},
+		{
+			name:    "Bad regex",
+			rule:    Rule{Kind: KindRegex, Pattern: "(", Action: ActionReject},
+			wantErr: true,
+		},
+		{
+			name:    "Unknown action",
+			rule:    Rule{Kind: KindWord, Pattern: "fornax", Action: "ban"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRule(tt.rule)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestModeratorLoad(t *testing.T) {
+	m := NewModerator()
+	if got := m.Check("fornax").Text; got != "fornax" {
+		t.Errorf("Check() before Load = %q, want %q", got, "fornax")
+	}
+
+	err := m.Load([]Rule{{Kind: KindWord, Pattern: "fornax", Action: ActionMask}})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := m.Check("fornax").Text; got != "****" {
+		t.Errorf("Check() after Load = %q, want %q", got, "****")
+	}
+
+	// A bad rule set leaves the old one in place.
+	err = m.Load([]Rule{{Kind: KindRegex, Pattern: "(", Action: ActionMask}})
+	if err == nil {
+		t.Fatal("Load() with a bad regex succeeded")
+	}
+	want := Result{Text: "****", Matches: []Match{{Rule: Rule{Kind: KindWord, Pattern: "fornax", Action: ActionMask}, Text: "fornax"}}}
+	if got := m.Check("fornax"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Check() after failed Load = %+v, want %+v", got, want)
+	}
+}
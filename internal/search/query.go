@@ -0,0 +1,92 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Query is a parsed search string. Operators (from:, since:, until:) are pulled out,
+// everything else is kept as free text for websearch_to_tsquery, quotes included.
+type Query struct {
+	Text  string
+	From  string
+	Since time.Time
+	Until time.Time
+}
+
+var dateLayouts = []string{time.RFC3339, "2006-01-02"}
+
+func ParseQuery(raw string) (Query, error) {
+	query := Query{}
+	text := []string{}
+	for _, token := range tokenize(raw) {
+		operator, value, found := strings.Cut(token, ":")
+		if !found || value == "" {
+			text = append(text, token)
+			continue
+		}
+		var err error
+		switch strings.ToLower(operator) {
+		case "from":
+			query.From = value
+		case "since":
+			query.Since, err = parseDate(value)
+		case "until":
+			query.Until, err = parseDate(value)
+		default:
+			text = append(text, token)
+		}
+		if err != nil {
+			return Query{}, fmt.Errorf("Invalid %s date: %w", operator, err)
+		}
+	}
+	query.Text = strings.Join(text, " ")
+
+	if query.Text == "" && query.From == "" && query.Since.IsZero() && query.Until.IsZero() {
+		return Query{}, errors.New("Search query is empty")
+	}
+	if !query.Since.IsZero() && !query.Until.IsZero() && !query.Since.Before(query.Until) {
+		return Query{}, errors.New("since must be before until")
+	}
+	return query, nil
+}
+
+func parseDate(value string) (time.Time, error) {
+	var err error
+	for _, layout := range dateLayouts {
+		var t time.Time
+		t, err = time.Parse(layout, value)
+		if err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// tokenize splits on whitespace but keeps "quoted phrases" together as one token.
+func tokenize(raw string) []string {
+	tokens := []string{}
+	var current strings.Builder
+	inQuotes := false
+	for _, r := range raw {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			current.WriteRune(r)
+		case unicode.IsSpace(r) && !inQuotes:
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens
+}
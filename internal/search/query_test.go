@@ -0,0 +1,74 @@
+package search
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    Query
+		wantErr bool
+	}{
+		{
+			name: "Plain text",
+			raw:  "hello world",
+			want: Query{Text: "hello world"},
+		},
+		{
+			name: "Phrase is kept together",
+			raw:  `"hello   world" -spam`,
+			want: Query{Text: `"hello   world" -spam`},
+		},
+		{
+			name: "Operators are extracted",
+			raw:  "from:alice since:2024-01-01 until:2024-02-01 gophers",
+			want: Query{
+				Text:  "gophers",
+				From:  "alice",
+				Since: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				Until: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "Colon inside a phrase is text",
+			raw:  `"from:alice"`,
+			want: Query{Text: `"from:alice"`},
+		},
+		{
+			name: "Unknown operator is text",
+			raw:  "http://example.com",
+			want: Query{Text: "http://example.com"},
+		},
+		{
+			name:    "Empty query",
+			raw:     "   ",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid date",
+			raw:     "since:yesterday",
+			wantErr: true,
+		},
+		{
+			name:    "Inverted range",
+			raw:     "since:2024-02-01 until:2024-01-01",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseQuery(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseQuery() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseQuery() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
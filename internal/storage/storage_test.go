@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	local, err := NewLocal(root, "/media/")
+	if err != nil {
+		t.Fatalf("NewLocal() error = %v", err)
+	}
+
+	err = local.Put(ctx, "media/abc.png", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	f, err := local.Open(ctx, "media/abc.png")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil || string(data) != "hello" {
+		t.Errorf("Open() read %q, %v, want %q", data, err, "hello")
+	}
+
+	entries, err := os.ReadDir(filepath.Join(root, "media"))
+	if err != nil || len(entries) != 1 {
+		t.Errorf("Put() left %d files behind, want 1", len(entries))
+	}
+
+	err = local.Delete(ctx, "media/abc.png")
+	if err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	_, err = local.Open(ctx, "media/abc.png")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Open() after Delete() error = %v, want fs.ErrNotExist", err)
+	}
+	err = local.Delete(ctx, "media/abc.png")
+	if err != nil {
+		t.Errorf("Delete() of a missing file error = %v, want nil", err)
+	}
+}
+
+func TestLocalURL(t *testing.T) {
+	local, err := NewLocal(t.TempDir(), "/media/")
+	if err != nil {
+		t.Fatalf("NewLocal() error = %v", err)
+	}
+	got := local.URL("media/abc.png")
+	if got != "/media/media/abc.png" {
+		t.Errorf("URL() = %q, want %q", got, "/media/media/abc.png")
+	}
+}
+
+func TestLocalInvalidKeys(t *testing.T) {
+	ctx := context.Background()
+	local, err := NewLocal(t.TempDir(), "/media")
+	if err != nil {
+		t.Fatalf("NewLocal() error = %v", err)
+	}
+
+	keys := []string{"", ".", "../escape.png", "/abs.png", "a/../../b.png", "a//b.png"}
+	for _, key := range keys {
+		t.Run(key, func(t *testing.T) {
+			err := local.Put(ctx, key, strings.NewReader("x"))
+			if !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("Put(%q) error = %v, want ErrInvalidKey", key, err)
+			}
+			_, err = local.Open(ctx, key)
+			if !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("Open(%q) error = %v, want ErrInvalidKey", key, err)
+			}
+		})
+	}
+}
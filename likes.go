@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/docherak/bd-chirpy/internal/auth"
+	"github.com/docherak/bd-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) handlerLikesCreate(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't parse UUID", err)
+		return
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error getting bearer token", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	dbChirp, err := cfg.getShareableChirp(r.Context(), userID, chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", err)
+		return
+	}
+
+	err = cfg.db.LikeChirp(r.Context(), database.LikeChirpParams{
+		UserID:  userID,
+		ChirpID: dbChirp.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't like chirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerLikesDelete(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't parse UUID", err)
+		return
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error getting bearer token", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	err = cfg.db.UnlikeChirp(r.Context(), database.UnlikeChirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unlike chirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUserLikesGet(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't parse UUID", err)
+		return
+	}
+
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	viewerID := cfg.getViewerID(r)
+	rows, err := cfg.db.ListLikedChirps(r.Context(), database.ListLikedChirpsParams{
+		UserID:          userID,
+		CursorCreatedAt: page.CreatedAt,
+		CursorID:        page.ID,
+		ViewerID:        viewerID,
+		PageSize:        int32(page.Limit + 1),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting liked chirps", err)
+		return
+	}
+
+	dbChirps := []database.Chirp{}
+	for _, row := range rows {
+		dbChirps = append(dbChirps, row.Chirp)
+	}
+
+	apiChirps, err := cfg.databaseChirpsToAPIChirps(r.Context(), viewerID, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting liked chirps", err)
+		return
+	}
+
+	// Liked chirps are paged by when they were liked, not when they were posted.
+	chirps := newChirpsPage(apiChirps, page.Limit)
+	if chirps.NextCursor != "" {
+		last := rows[page.Limit-1]
+		chirps.NextCursor = encodeCursor(last.LikedAt, last.Chirp.ID)
+	}
+
+	respondWithJSON(w, http.StatusOK, chirps)
+}
+
+// addLikes fills in like counts, and whether the viewer liked each chirp when there is a viewer.
+func (cfg *apiConfig) addLikes(ctx context.Context, viewerID uuid.NullUUID, apiChirps []Chirp) error {
+	ids := chirpIDs(apiChirps)
+	likeCounts, err := cfg.db.CountLikesForChirps(ctx, ids)
+	if err != nil {
+		return err
+	}
+	likeCountByID := map[uuid.UUID]int64{}
+	for _, row := range likeCounts {
+		likeCountByID[row.ChirpID] = row.LikeCount
+	}
+
+	for i := range apiChirps {
+		apiChirps[i].LikeCount = likeCountByID[apiChirps[i].ID]
+	}
+
+	if !viewerID.Valid {
+		return nil
+	}
+
+	likedIDs, err := cfg.db.ListLikedChirpIDs(ctx, database.ListLikedChirpIDsParams{
+		UserID:   viewerID.UUID,
+		ChirpIds: ids,
+	})
+	if err != nil {
+		return err
+	}
+	liked := map[uuid.UUID]bool{}
+	for _, chirpID := range likedIDs {
+		liked[chirpID] = true
+	}
+
+	for i := range apiChirps {
+		likedByMe := liked[apiChirps[i].ID]
+		apiChirps[i].LikedByMe = &likedByMe
+	}
+	return nil
+}
@@ -1,22 +1,34 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"github.com/docherak/bd-chirpy/internal/database"
+	"github.com/docherak/bd-chirpy/internal/moderation"
+	"github.com/docherak/bd-chirpy/internal/storage"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"sync/atomic"
+	"time"
 )
 
 type apiConfig struct {
 	fileserverHits atomic.Int32
 	db             *database.Queries
+	dbConn         *sql.DB
 	env            string
 	jwtSecret      string
 	polkApiSecret  string
+	storage        storage.Storage
+	moderator      *moderation.Moderator
+
+	chirpEditWindow time.Duration
+	chirpURLLength  int
+	trashRetention  time.Duration
 }
 
 func main() {
@@ -48,42 +60,162 @@ func main() {
 	}
 	dbQueries := database.New(dbConn)
 
+	mediaDir := os.Getenv("MEDIA_DIR")
+	if mediaDir == "" {
+		mediaDir = "media"
+	}
+	mediaStorage, err := storage.NewLocal(mediaDir, "/media")
+	if err != nil {
+		log.Fatalf("Error opening media storage: %s", err)
+	}
+
+	chirpEditWindow := getEnvDuration("CHIRP_EDIT_WINDOW", time.Hour)
+	chirpURLLength := getEnvInt("CHIRP_URL_LENGTH", 23)
+	trashRetention := getEnvDuration("TRASH_RETENTION", 30*24*time.Hour)
+	trendsInterval := getEnvDuration("TRENDS_INTERVAL", 5*time.Minute)
+	trendsWindow := getEnvDuration("TRENDS_WINDOW", time.Hour)
+
 	const port = "8080"
 	const filepathRoot = "."
 
 	apiCfg := apiConfig{
 		fileserverHits: atomic.Int32{},
 		db:             dbQueries,
+		dbConn:         dbConn,
 		env:            environment,
 		jwtSecret:      jwtSecret,
 		polkApiSecret:  polkaApiSecret,
+		storage:        mediaStorage,
+		moderator:      moderation.NewModerator(),
+
+		chirpEditWindow: chirpEditWindow,
+		chirpURLLength:  chirpURLLength,
+		trashRetention:  trashRetention,
+	}
+
+	// Chirps must not go out unfiltered, so the first load has to succeed.
+	err = apiCfg.reloadModerationRules(context.Background())
+	if err != nil {
+		log.Fatalf("Error loading moderation rules: %s", err)
 	}
 
 	mux := http.NewServeMux()
 	fsHandler := apiCfg.middlewareMetricsInc(http.StripPrefix("/app", http.FileServer(http.Dir(filepathRoot))))
 	mux.Handle("/app/", fsHandler)
+	mux.Handle("GET /media/", middlewareCacheImmutable(http.StripPrefix("/media", http.FileServer(http.Dir(mediaDir)))))
 
 	mux.HandleFunc("GET /api/healthz", handlerReadiness)
 	// In REST, it's conventional to name all of your endpoints after the resource that they represent and for the name to be plural.
 	mux.HandleFunc("POST /api/users", apiCfg.handlerUsersCreate)
 	mux.HandleFunc("PUT /api/users", apiCfg.handlerUsersUpdate)
+	mux.HandleFunc("GET /api/users/{userRef}", apiCfg.handlerProfilesGet)
+	mux.HandleFunc("PATCH /api/users/me/profile", apiCfg.handlerProfilesUpdate)
+	mux.HandleFunc("POST /api/users/{userID}/follow", apiCfg.handlerFollowsCreate)
+	mux.HandleFunc("DELETE /api/users/{userID}/follow", apiCfg.handlerFollowsDelete)
+	mux.HandleFunc("GET /api/users/{userID}/followers", apiCfg.handlerFollowersGet)
+	mux.HandleFunc("GET /api/users/{userID}/following", apiCfg.handlerFollowingGet)
+	mux.HandleFunc("GET /api/users/{userID}/likes", apiCfg.handlerUserLikesGet)
+	mux.HandleFunc("GET /api/users/me/mentions", apiCfg.handlerMentionsGet)
+	mux.HandleFunc("GET /api/users/me/trash", apiCfg.handlerTrashGet)
+	mux.HandleFunc("GET /api/users/me/drafts", apiCfg.handlerDraftsGet)
+	mux.HandleFunc("GET /api/users/me/scheduled", apiCfg.handlerScheduledGet)
+	mux.HandleFunc("GET /api/users/me/bookmarks", apiCfg.handlerBookmarksGet)
+	mux.HandleFunc("GET /api/users/me/blocks", apiCfg.handlerBlocksGet)
+	mux.HandleFunc("POST /api/users/me/blocks", apiCfg.handlerBlocksCreate)
+	mux.HandleFunc("DELETE /api/users/me/blocks/{userID}", apiCfg.handlerBlocksDelete)
+	mux.HandleFunc("GET /api/users/me/mutes", apiCfg.handlerMutesGet)
+	mux.HandleFunc("POST /api/users/me/mutes", apiCfg.handlerMutesCreate)
+	mux.HandleFunc("DELETE /api/users/me/mutes/{userID}", apiCfg.handlerMutesDelete)
+	mux.HandleFunc("GET /api/users/me/muted-words", apiCfg.handlerMutedWordsGet)
+	mux.HandleFunc("POST /api/users/me/muted-words", apiCfg.handlerMutedWordsCreate)
+	mux.HandleFunc("DELETE /api/users/me/muted-words/{mutedWordID}", apiCfg.handlerMutedWordsDelete)
+	mux.HandleFunc("GET /api/timeline", apiCfg.handlerTimelineGet)
+	mux.HandleFunc("POST /api/media", apiCfg.handlerMediaCreate)
 	mux.HandleFunc("POST /api/chirps", apiCfg.handlerChirpsCreate)
 	mux.HandleFunc("GET /api/chirps", apiCfg.handlerChirpsGetAll)
+	mux.HandleFunc("GET /api/chirps/search", apiCfg.handlerChirpsSearch)
 	mux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.handlerChirpsGetSingle)
+	mux.HandleFunc("PUT /api/chirps/{chirpID}", apiCfg.handlerChirpsUpdate)
 	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.handlerChirpsDeleteSingle)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/revisions", apiCfg.handlerChirpRevisionsGet)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/restore", apiCfg.handlerChirpsRestore)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/thread", apiCfg.handlerChirpsGetThread)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/likes", apiCfg.handlerLikesCreate)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/likes", apiCfg.handlerLikesDelete)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/bookmark", apiCfg.handlerBookmarksCreate)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/bookmark", apiCfg.handlerBookmarksDelete)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/pin", apiCfg.handlerPinsCreate)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/pin", apiCfg.handlerPinsDelete)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/poll/votes", apiCfg.handlerPollVotesCreate)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/rechirps", apiCfg.handlerRechirpsCreate)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/rechirps", apiCfg.handlerRechirpsDelete)
+	mux.HandleFunc("POST /api/reports", apiCfg.handlerReportsCreate)
 	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.handlerPolkaEvents)
 	mux.HandleFunc("POST /api/login", apiCfg.handlerLogin)
 	mux.HandleFunc("POST /api/refresh", apiCfg.handlerTokenRefresh)
 	mux.HandleFunc("POST /api/revoke", apiCfg.handlerTokenRevoke)
 
+	mux.HandleFunc("GET /api/hashtags/{tag}/chirps", apiCfg.handlerHashtagChirpsGet)
+	mux.HandleFunc("GET /api/trends", apiCfg.handlerTrendsGet)
+
 	mux.HandleFunc("GET /admin/metrics", apiCfg.handlerMetrics)
 	mux.HandleFunc("POST /admin/reset", apiCfg.handlerReset)
+	mux.HandleFunc("GET /admin/moderation/rules", apiCfg.handlerModerationRulesGet)
+	mux.HandleFunc("POST /admin/moderation/rules", apiCfg.handlerModerationRulesCreate)
+	mux.HandleFunc("PUT /admin/moderation/rules/{ruleID}", apiCfg.handlerModerationRulesUpdate)
+	mux.HandleFunc("DELETE /admin/moderation/rules/{ruleID}", apiCfg.handlerModerationRulesDelete)
+	mux.HandleFunc("GET /admin/moderation/flags", apiCfg.handlerModerationFlagsGet)
+	mux.HandleFunc("POST /admin/users/{userID}/suspension", apiCfg.handlerUserSuspend)
+	mux.HandleFunc("DELETE /admin/users/{userID}/suspension", apiCfg.handlerUserUnsuspend)
+	mux.HandleFunc("POST /admin/users/{userID}/shadowban", apiCfg.handlerUserShadowban)
+	mux.HandleFunc("DELETE /admin/users/{userID}/shadowban", apiCfg.handlerUserUnshadowban)
+	mux.HandleFunc("GET /admin/reports", apiCfg.handlerReportsGet)
+	mux.HandleFunc("GET /admin/reports/{reportID}", apiCfg.handlerReportsGetSingle)
+	mux.HandleFunc("POST /admin/reports/{reportID}/claim", apiCfg.handlerReportsClaim)
+	mux.HandleFunc("POST /admin/reports/{reportID}/resolve", apiCfg.handlerReportsResolve)
 
 	srv := &http.Server{
 		Addr:    ":" + port,
-		Handler: mux,
+		Handler: apiCfg.middlewareRejectSuspended(mux),
 	}
 
+	go runPeriodically(context.Background(), "trends", trendsInterval, func(ctx context.Context) error {
+		return apiCfg.computeTrends(ctx, trendsWindow)
+	})
+	go runPeriodically(context.Background(), "trash purge", time.Hour, apiCfg.purgeTrash)
+	go runPeriodically(context.Background(), "media purge", time.Hour, apiCfg.purgeOrphanedMedia)
+	go runPeriodically(context.Background(), "media processing", 5*time.Second, apiCfg.processPendingMedia)
+	go runPeriodically(context.Background(), "scheduled publishing", 30*time.Second, apiCfg.publishScheduledChirps)
+	go runPeriodically(context.Background(), "moderation rules reload", time.Minute, apiCfg.reloadModerationRules)
+	go runPeriodically(context.Background(), "suspension expiry", time.Minute, apiCfg.liftExpiredSuspensions)
+	go runPeriodically(context.Background(), "muted words purge", time.Hour, apiCfg.purgeExpiredMutedWords)
+
 	log.Printf("Serving on: http://localhost:%s\n", port)
 	log.Fatal(srv.ListenAndServe())
 }
+
+// getEnvDuration reads an optional duration such as "90s" or "1h", falling back when unset.
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Fatalf("%s must be a positive duration: %s", key, value)
+	}
+	return d
+}
+
+// getEnvInt reads an optional non-negative integer setting, falling back when unset.
+func getEnvInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		log.Fatalf("%s must be a non-negative integer: %s", key, value)
+	}
+	return n
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/docherak/bd-chirpy/internal/auth"
+	"github.com/docherak/bd-chirpy/internal/database"
+	"github.com/docherak/bd-chirpy/internal/imaging"
+	"github.com/google/uuid"
+)
+
+const (
+	maxMediaSize       = 5 << 20
+	mediaQuota         = 100 << 20
+	maxChirpMedia      = 4
+	orphanedMediaGrace = 24 * time.Hour
+)
+
+// mediaExtensions lists the content types that can be uploaded, as sniffed from the file
+// itself rather than taken from the client.
+var mediaExtensions = map[string]string{
+	"image/gif":  ".gif",
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+var errMediaUnavailable = errors.New("media not found or already attached")
+
+// Attachment is an uploaded file. Images start out "pending" and get their dimensions,
+// blurhash and resized variants once the media processing worker has been through them.
+type Attachment struct {
+	ID          uuid.UUID `json:"id"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	URL         string    `json:"url"`
+	Status      string    `json:"status"`
+	Width       *int32    `json:"width,omitempty"`
+	Height      *int32    `json:"height,omitempty"`
+	Blurhash    string    `json:"blurhash,omitempty"`
+
+	Variants []AttachmentVariant `json:"variants"`
+}
+
+type AttachmentVariant struct {
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	Width       int32  `json:"width"`
+	Height      int32  `json:"height"`
+	ContentType string `json:"content_type"`
+}
+
+func (cfg *apiConfig) handlerMediaCreate(w http.ResponseWriter, r *http.Request) {
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error getting bearer token", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	// Leave some room for the multipart framing around the file itself.
+	r.Body = http.MaxBytesReader(w, r.Body, maxMediaSize+1<<20)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, "File is too large", err)
+			return
+		}
+		respondWithError(w, http.StatusBadRequest, "Couldn't read file", err)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't read file", err)
+		return
+	}
+	if len(data) > maxMediaSize {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "File is too large", nil)
+		return
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := mediaExtensions[contentType]
+	if !ok {
+		respondWithError(w, http.StatusUnsupportedMediaType, "Unsupported media type", nil)
+		return
+	}
+
+	// Metadata is stripped before the file is stored, since it is public as soon as it is.
+	data, err = imaging.StripMetadata(data, contentType)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't process image", err)
+		return
+	}
+
+	usage, err := cfg.db.GetMediaUsage(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check media quota", err)
+		return
+	}
+	if usage+int64(len(data)) > mediaQuota {
+		respondWithError(w, http.StatusForbidden, "Media quota exceeded", nil)
+		return
+	}
+
+	mediaID := uuid.New()
+	key := mediaID.String() + ext
+	err = cfg.storage.Put(r.Context(), key, bytes.NewReader(data))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't store file", err)
+		return
+	}
+
+	media, err := cfg.db.CreateMedia(r.Context(), database.CreateMediaParams{
+		ID:          mediaID,
+		UserID:      userID,
+		ContentType: contentType,
+		SizeBytes:   int64(len(data)),
+		StorageKey:  key,
+	})
+	if err != nil {
+		cfg.storage.Delete(context.Background(), key)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save media", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, cfg.databaseMediaToAttachment(media, nil))
+}
+
+func (cfg *apiConfig) databaseMediaToAttachment(media database.Medium, variants []database.MediaVariant) Attachment {
+	attachment := Attachment{
+		ID:          media.ID,
+		ContentType: media.ContentType,
+		Size:        media.SizeBytes,
+		URL:         cfg.storage.URL(media.StorageKey),
+		Status:      media.Status,
+		Blurhash:    media.Blurhash.String,
+		Variants:    []AttachmentVariant{},
+	}
+	if media.Width.Valid && media.Height.Valid {
+		attachment.Width = &media.Width.Int32
+		attachment.Height = &media.Height.Int32
+	}
+	for _, variant := range variants {
+		attachment.Variants = append(attachment.Variants, AttachmentVariant{
+			Name:        variant.Name,
+			URL:         cfg.storage.URL(variant.StorageKey),
+			Width:       variant.Width,
+			Height:      variant.Height,
+			ContentType: variant.ContentType,
+		})
+	}
+	return attachment
+}
+
+// validateMediaIDs checks the media_ids of a new chirp. Whether they exist and belong to the
+// author is only known once attachMedia runs.
+func validateMediaIDs(mediaIDs []uuid.UUID) error {
+	if len(mediaIDs) > maxChirpMedia {
+		return errors.New("Too many attachments")
+	}
+	seen := map[uuid.UUID]bool{}
+	for _, id := range mediaIDs {
+		if seen[id] {
+			return errors.New("Duplicate attachment")
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// attachMedia links the author's uploads to a chirp in the given order. Each upload can only
+// ever belong to one chirp.
+func attachMedia(ctx context.Context, q *database.Queries, chirp database.Chirp, mediaIDs []uuid.UUID) error {
+	if len(mediaIDs) == 0 {
+		return nil
+	}
+	attached, err := q.AttachMedia(ctx, database.AttachMediaParams{
+		ChirpID:  uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		MediaIds: mediaIDs,
+		UserID:   chirp.UserID,
+	})
+	if err != nil {
+		return err
+	}
+	if attached != int64(len(mediaIDs)) {
+		return errMediaUnavailable
+	}
+	return nil
+}
+
+func (cfg *apiConfig) addAttachments(ctx context.Context, viewerID uuid.NullUUID, apiChirps []Chirp) error {
+	rows, err := cfg.db.ListMediaForChirps(ctx, chirpIDs(apiChirps))
+	if err != nil {
+		return err
+	}
+	variantsByID, err := cfg.getMediaVariants(ctx, rows)
+	if err != nil {
+		return err
+	}
+	attachmentsByID := map[uuid.UUID][]Attachment{}
+	for _, row := range rows {
+		attachment := cfg.databaseMediaToAttachment(row, variantsByID[row.ID])
+		attachmentsByID[row.ChirpID.UUID] = append(attachmentsByID[row.ChirpID.UUID], attachment)
+	}
+
+	for i := range apiChirps {
+		apiChirps[i].Attachments = []Attachment{}
+		// Tombstones don't show their content, attachments included.
+		if apiChirps[i].IsDeleted {
+			continue
+		}
+		if attachments, ok := attachmentsByID[apiChirps[i].ID]; ok {
+			apiChirps[i].Attachments = attachments
+		}
+	}
+	return nil
+}
+
+func (cfg *apiConfig) getMediaVariants(ctx context.Context, media []database.Medium) (map[uuid.UUID][]database.MediaVariant, error) {
+	variantsByID := map[uuid.UUID][]database.MediaVariant{}
+	if len(media) == 0 {
+		return variantsByID, nil
+	}
+	ids := make([]uuid.UUID, 0, len(media))
+	for _, m := range media {
+		ids = append(ids, m.ID)
+	}
+	variants, err := cfg.db.ListVariantsForMedia(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, variant := range variants {
+		variantsByID[variant.MediaID] = append(variantsByID[variant.MediaID], variant)
+	}
+	return variantsByID, nil
+}
+
+// purgeOrphanedMedia removes uploads that never made it into a chirp, or whose chirp is gone,
+// from both the database and storage.
+func (cfg *apiConfig) purgeOrphanedMedia(ctx context.Context) error {
+	orphans, err := cfg.db.ListOrphanedMedia(ctx, time.Now().UTC().Add(-orphanedMediaGrace))
+	if err != nil {
+		return err
+	}
+	variantsByID, err := cfg.getMediaVariants(ctx, orphans)
+	if err != nil {
+		return err
+	}
+	for _, media := range orphans {
+		for _, variant := range variantsByID[media.ID] {
+			err := cfg.storage.Delete(ctx, variant.StorageKey)
+			if err != nil {
+				return err
+			}
+		}
+		err := cfg.storage.Delete(ctx, media.StorageKey)
+		if err != nil {
+			return err
+		}
+		err = cfg.db.DeleteMedia(ctx, media.ID)
+		if err != nil {
+			return err
+		}
+	}
+	if len(orphans) > 0 {
+		log.Printf("Purged %d orphaned media files", len(orphans))
+	}
+	return nil
+}
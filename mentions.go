@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/docherak/bd-chirpy/internal/auth"
+	"github.com/docherak/bd-chirpy/internal/database"
+	"github.com/docherak/bd-chirpy/internal/entities"
+	"github.com/google/uuid"
+)
+
+type Entities struct {
+	Hashtags []HashtagEntity `json:"hashtags"`
+	Mentions []MentionEntity `json:"mentions"`
+}
+
+type HashtagEntity struct {
+	Tag   string `json:"tag"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+type MentionEntity struct {
+	Handle string    `json:"handle"`
+	UserID uuid.UUID `json:"user_id"`
+	Start  int       `json:"start"`
+	End    int       `json:"end"`
+}
+
+func (cfg *apiConfig) handlerMentionsGet(w http.ResponseWriter, r *http.Request) {
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error getting bearer token", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	dbChirps, err := cfg.db.ListMentioningChirps(r.Context(), database.ListMentioningChirpsParams{
+		UserID:          userID,
+		CursorCreatedAt: page.CreatedAt,
+		CursorID:        page.ID,
+		PageSize:        int32(page.Limit + 1),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting mentions", err)
+		return
+	}
+
+	apiChirps, err := cfg.databaseChirpsToAPIChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting mentions", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newChirpsPage(apiChirps, page.Limit))
+}
+
+// storeMentions resolves @handles in the chirp body to accounts and records who was mentioned.
+// Handles that don't belong to anyone are left as plain text.
+func storeMentions(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	handles := []string{}
+	for _, mention := range entities.ParseMentions(chirp.Body) {
+		handles = append(handles, strings.ToLower(mention.Text))
+	}
+	if len(handles) == 0 {
+		return nil
+	}
+
+	users, err := q.GetUsersByHandles(ctx, handles)
+	if err != nil {
+		return err
+	}
+
+	// People who blocked the author aren't mentioned, so they aren't notified or shown the chirp.
+	userIDs := make([]uuid.UUID, 0, len(users))
+	for _, user := range users {
+		userIDs = append(userIDs, user.ID)
+	}
+	blockerIDs, err := q.ListBlockersAmong(ctx, database.ListBlockersAmongParams{
+		BlockedID: chirp.UserID,
+		UserIds:   userIDs,
+	})
+	if err != nil {
+		return err
+	}
+	blockers := map[uuid.UUID]bool{}
+	for _, blockerID := range blockerIDs {
+		blockers[blockerID] = true
+	}
+
+	for _, user := range users {
+		if blockers[user.ID] {
+			continue
+		}
+		err = q.AddMention(ctx, database.AddMentionParams{
+			ChirpID: chirp.ID,
+			UserID:  user.ID,
+			Handle:  strings.ToLower(user.Handle.String),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addEntities locates hashtags and mentions in each body. Mentions are matched against the
+// handles stored when the chirp was created, so renaming an account doesn't re-target old chirps.
+func (cfg *apiConfig) addEntities(ctx context.Context, viewerID uuid.NullUUID, apiChirps []Chirp) error {
+	mentions, err := cfg.db.ListMentionsForChirps(ctx, chirpIDs(apiChirps))
+	if err != nil {
+		return err
+	}
+	mentionedUsers := map[uuid.UUID]map[string]uuid.UUID{}
+	for _, mention := range mentions {
+		if mentionedUsers[mention.ChirpID] == nil {
+			mentionedUsers[mention.ChirpID] = map[string]uuid.UUID{}
+		}
+		mentionedUsers[mention.ChirpID][mention.Handle] = mention.UserID
+	}
+
+	for i := range apiChirps {
+		chirpEntities := &Entities{
+			Hashtags: []HashtagEntity{},
+			Mentions: []MentionEntity{},
+		}
+		for _, hashtag := range entities.ParseHashtags(apiChirps[i].Body) {
+			chirpEntities.Hashtags = append(chirpEntities.Hashtags, HashtagEntity{
+				Tag:   entities.NormalizeTag(hashtag.Text),
+				Start: hashtag.Start,
+				End:   hashtag.End,
+			})
+		}
+		for _, mention := range entities.ParseMentions(apiChirps[i].Body) {
+			userID, ok := mentionedUsers[apiChirps[i].ID][strings.ToLower(mention.Text)]
+			if !ok {
+				continue
+			}
+			chirpEntities.Mentions = append(chirpEntities.Mentions, MentionEntity{
+				Handle: mention.Text,
+				UserID: userID,
+				Start:  mention.Start,
+				End:    mention.End,
+			})
+		}
+		apiChirps[i].Entities = chirpEntities
+	}
+	return nil
+}
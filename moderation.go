@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/docherak/bd-chirpy/internal/auth"
+	"github.com/docherak/bd-chirpy/internal/database"
+	"github.com/docherak/bd-chirpy/internal/moderation"
+	"github.com/google/uuid"
+)
+
+type ModerationRule struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Kind      string    `json:"kind"`
+	Pattern   string    `json:"pattern"`
+	Action    string    `json:"action"`
+}
+
+type ModerationFlag struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	ChirpID   uuid.UUID  `json:"chirp_id"`
+	RuleID    *uuid.UUID `json:"rule_id,omitempty"`
+	Matched   string     `json:"matched"`
+}
+
+type moderationFlagsPage struct {
+	Flags      []ModerationFlag `json:"flags"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+type moderationRuleParameters struct {
+	Kind    string `json:"kind"`
+	Pattern string `json:"pattern"`
+	Action  string `json:"action"`
+}
+
+// reloadModerationRules swaps the rules in the moderation_rules table into the running
+// moderator. It runs at startup, after every change through the admin API and periodically,
+// so edits made directly in the database or by another instance are picked up too.
+func (cfg *apiConfig) reloadModerationRules(ctx context.Context) error {
+	dbRules, err := cfg.db.ListModerationRules(ctx)
+	if err != nil {
+		return err
+	}
+	rules := make([]moderation.Rule, 0, len(dbRules))
+	for _, dbRule := range dbRules {
+		rules = append(rules, moderation.Rule{
+			ID:      dbRule.ID,
+			Kind:    moderation.Kind(dbRule.Kind),
+			Pattern: dbRule.Pattern,
+			Action:  moderation.Action(dbRule.Action),
+		})
+	}
+	return cfg.moderator.Load(rules)
+}
+
+// moderate runs text through the moderation filters. It returns the text with anything masked
+// and the matches to be flagged for review, or an error if a rule rejects it.
+func (cfg *apiConfig) moderate(text string) (string, []moderation.Match, error) {
+	result := cfg.moderator.Check(text)
+	if result.Rejected() {
+		return "", nil, errors.New("Chirp contains language that isn't allowed")
+	}
+	return result.Text, result.Flags(), nil
+}
+
+// storeModerationFlags queues a chirp for review for every flagging rule it matched.
+func storeModerationFlags(ctx context.Context, q *database.Queries, chirp database.Chirp, flags []moderation.Match) error {
+	for _, flag := range flags {
+		err := q.CreateModerationFlag(ctx, database.CreateModerationFlagParams{
+			ChirpID: chirp.ID,
+			RuleID:  uuid.NullUUID{UUID: flag.Rule.ID, Valid: true},
+			Matched: flag.Text,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// requireModerator checks that the request comes from a moderator and answers it with an
+// error if not.
+func (cfg *apiConfig) requireModerator(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error getting bearer token", err)
+		return uuid.Nil, false
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return uuid.Nil, false
+	}
+
+	user, err := cfg.db.GetUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't get user", err)
+		return uuid.Nil, false
+	}
+	if !user.IsModerator {
+		respondWithError(w, http.StatusForbidden, "Forbidden: Moderators only", nil)
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+func (cfg *apiConfig) handlerModerationRulesGet(w http.ResponseWriter, r *http.Request) {
+	if _, ok := cfg.requireModerator(w, r); !ok {
+		return
+	}
+
+	dbRules, err := cfg.db.ListModerationRules(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting moderation rules", err)
+		return
+	}
+
+	rules := []ModerationRule{}
+	for _, dbRule := range dbRules {
+		rules = append(rules, databaseModerationRuleToAPIModerationRule(dbRule))
+	}
+
+	respondWithJSON(w, http.StatusOK, rules)
+}
+
+func (cfg *apiConfig) handlerModerationRulesCreate(w http.ResponseWriter, r *http.Request) {
+	if _, ok := cfg.requireModerator(w, r); !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := moderationRuleParameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	err = validateModerationRule(params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	dbRule, err := cfg.db.CreateModerationRule(r.Context(), database.CreateModerationRuleParams{
+		Kind:    params.Kind,
+		Pattern: params.Pattern,
+		Action:  params.Action,
+	})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "Rule already exists", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create moderation rule", err)
+		return
+	}
+
+	err = cfg.reloadModerationRules(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reload moderation rules", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, databaseModerationRuleToAPIModerationRule(dbRule))
+}
+
+func (cfg *apiConfig) handlerModerationRulesUpdate(w http.ResponseWriter, r *http.Request) {
+	ruleID, err := uuid.Parse(r.PathValue("ruleID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't parse UUID", err)
+		return
+	}
+
+	if _, ok := cfg.requireModerator(w, r); !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := moderationRuleParameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	err = validateModerationRule(params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	dbRule, err := cfg.db.UpdateModerationRule(r.Context(), database.UpdateModerationRuleParams{
+		ID:      ruleID,
+		Kind:    params.Kind,
+		Pattern: params.Pattern,
+		Action:  params.Action,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Rule not found", err)
+		return
+	}
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "Rule already exists", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update moderation rule", err)
+		return
+	}
+
+	err = cfg.reloadModerationRules(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reload moderation rules", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, databaseModerationRuleToAPIModerationRule(dbRule))
+}
+
+func (cfg *apiConfig) handlerModerationRulesDelete(w http.ResponseWriter, r *http.Request) {
+	ruleID, err := uuid.Parse(r.PathValue("ruleID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't parse UUID", err)
+		return
+	}
+
+	if _, ok := cfg.requireModerator(w, r); !ok {
+		return
+	}
+
+	deleted, err := cfg.db.DeleteModerationRule(r.Context(), ruleID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete moderation rule", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Rule not found", nil)
+		return
+	}
+
+	err = cfg.reloadModerationRules(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reload moderation rules", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerModerationFlagsGet(w http.ResponseWriter, r *http.Request) {
+	if _, ok := cfg.requireModerator(w, r); !ok {
+		return
+	}
+
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	dbFlags, err := cfg.db.ListModerationFlags(r.Context(), database.ListModerationFlagsParams{
+		CursorCreatedAt: page.CreatedAt,
+		CursorID:        page.ID,
+		PageSize:        int32(page.Limit + 1),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting moderation flags", err)
+		return
+	}
+
+	flags := moderationFlagsPage{Flags: []ModerationFlag{}}
+	for _, dbFlag := range dbFlags {
+		flag := ModerationFlag{
+			ID:        dbFlag.ID,
+			CreatedAt: dbFlag.CreatedAt,
+			ChirpID:   dbFlag.ChirpID,
+			Matched:   dbFlag.Matched,
+		}
+		if dbFlag.RuleID.Valid {
+			flag.RuleID = &dbFlag.RuleID.UUID
+		}
+		flags.Flags = append(flags.Flags, flag)
+	}
+	if len(flags.Flags) > page.Limit {
+		flags.Flags = flags.Flags[:page.Limit]
+		last := flags.Flags[page.Limit-1]
+		flags.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	respondWithJSON(w, http.StatusOK, flags)
+}
+
+func validateModerationRule(params moderationRuleParameters) error {
+	return moderation.ValidateRule(moderation.Rule{
+		Kind:    moderation.Kind(params.Kind),
+		Pattern: params.Pattern,
+		Action:  moderation.Action(params.Action),
+	})
+}
+
+func databaseModerationRuleToAPIModerationRule(dbRule database.ModerationRule) ModerationRule {
+	return ModerationRule{
+		ID:        dbRule.ID,
+		CreatedAt: dbRule.CreatedAt,
+		UpdatedAt: dbRule.UpdatedAt,
+		Kind:      dbRule.Kind,
+		Pattern:   dbRule.Pattern,
+		Action:    dbRule.Action,
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/docherak/bd-chirpy/internal/auth"
+	"github.com/docherak/bd-chirpy/internal/chirptext"
+	"github.com/docherak/bd-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	mutedWordMatchWord      = "word"
+	mutedWordMatchSubstring = "substring"
+)
+
+const (
+	maxMutedWords        = 100
+	maxMutedPhraseLength = 100
+)
+
+// MutedWord hides chirps containing a phrase from the user's own feeds and searches.
+type MutedWord struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	Phrase    string     `json:"phrase"`
+	Match     string     `json:"match"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+func (cfg *apiConfig) handlerMutedWordsCreate(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Phrase    string     `json:"phrase"`
+		Match     string     `json:"match"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error getting bearer token", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	phrase, err := validateMutedPhrase(params.Phrase)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	wholeWord, err := parseMutedWordMatch(params.Match)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	expiresAt := sql.NullTime{}
+	if params.ExpiresAt != nil {
+		if !params.ExpiresAt.After(time.Now()) {
+			respondWithError(w, http.StatusBadRequest, "expires_at must be in the future", nil)
+			return
+		}
+		expiresAt = sql.NullTime{Time: params.ExpiresAt.UTC(), Valid: true}
+	}
+
+	count, err := cfg.db.CountMutedWords(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't mute phrase", err)
+		return
+	}
+	if count >= maxMutedWords {
+		respondWithError(w, http.StatusBadRequest, "Too many muted words", nil)
+		return
+	}
+
+	var dbMutedWord database.MutedWord
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		// An expired filter for the same phrase may not have been purged yet. It no longer
+		// applies, so it shouldn't stop the phrase from being muted again.
+		err := q.DeleteExpiredMutedPhrase(r.Context(), database.DeleteExpiredMutedPhraseParams{
+			UserID: userID,
+			Phrase: phrase,
+		})
+		if err != nil {
+			return err
+		}
+		dbMutedWord, err = q.CreateMutedWord(r.Context(), database.CreateMutedWordParams{
+			UserID:    userID,
+			Phrase:    phrase,
+			WholeWord: wholeWord,
+			Pattern:   mutedWordPattern(phrase, wholeWord),
+			ExpiresAt: expiresAt,
+		})
+		return err
+	})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "Phrase is already muted", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't mute phrase", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, databaseMutedWordToAPIMutedWord(dbMutedWord))
+}
+
+func (cfg *apiConfig) handlerMutedWordsDelete(w http.ResponseWriter, r *http.Request) {
+	mutedWordID, err := uuid.Parse(r.PathValue("mutedWordID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't parse UUID", err)
+		return
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error getting bearer token", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteMutedWord(r.Context(), database.DeleteMutedWordParams{
+		ID:     mutedWordID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unmute phrase", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Muted word not found", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerMutedWordsGet(w http.ResponseWriter, r *http.Request) {
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error getting bearer token", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	dbMutedWords, err := cfg.db.ListMutedWords(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting muted words", err)
+		return
+	}
+
+	mutedWords := []MutedWord{}
+	for _, dbMutedWord := range dbMutedWords {
+		mutedWords = append(mutedWords, databaseMutedWordToAPIMutedWord(dbMutedWord))
+	}
+
+	respondWithJSON(w, http.StatusOK, mutedWords)
+}
+
+// purgeExpiredMutedWords removes filters past their expiry. They already stopped applying
+// when they expired, this only keeps the table small.
+func (cfg *apiConfig) purgeExpiredMutedWords(ctx context.Context) error {
+	_, err := cfg.db.DeleteExpiredMutedWords(ctx)
+	return err
+}
+
+func validateMutedPhrase(phrase string) (string, error) {
+	phrase = strings.TrimSpace(chirptext.Normalize(phrase))
+	if phrase == "" {
+		return "", errors.New("Phrase can't be empty")
+	}
+	if utf8.RuneCountInString(phrase) > maxMutedPhraseLength {
+		return "", errors.New("Phrase is too long")
+	}
+	return phrase, nil
+}
+
+// parseMutedWordMatch reports whether a filter matches whole words only. That is the default,
+// so muting "cat" doesn't hide chirps about education.
+func parseMutedWordMatch(match string) (bool, error) {
+	switch match {
+	case "", mutedWordMatchWord:
+		return true, nil
+	case mutedWordMatchSubstring:
+		return false, nil
+	}
+	return false, errors.New("match must be \"word\" or \"substring\"")
+}
+
+// mutedWordPattern builds the regular expression the database matches chirps against,
+// case-insensitively. Whole-word filters must not touch a letter, digit or underscore on
+// either side.
+func mutedWordPattern(phrase string, wholeWord bool) string {
+	pattern := regexp.QuoteMeta(phrase)
+	if !wholeWord {
+		return pattern
+	}
+	return `(^|[^[:alnum:]_])` + pattern + `($|[^[:alnum:]_])`
+}
+
+func databaseMutedWordToAPIMutedWord(dbMutedWord database.MutedWord) MutedWord {
+	mutedWord := MutedWord{
+		ID:        dbMutedWord.ID,
+		CreatedAt: dbMutedWord.CreatedAt,
+		Phrase:    dbMutedWord.Phrase,
+		Match:     mutedWordMatchSubstring,
+	}
+	if dbMutedWord.WholeWord {
+		mutedWord.Match = mutedWordMatchWord
+	}
+	if dbMutedWord.ExpiresAt.Valid {
+		mutedWord.ExpiresAt = &dbMutedWord.ExpiresAt.Time
+	}
+	return mutedWord
+}
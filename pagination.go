@@ -0,0 +1,99 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type chirpsPage struct {
+	Pinned     []Chirp `json:"pinned,omitempty"`
+	Chirps     []Chirp `json:"chirps"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+type pageParams struct {
+	Limit     int
+	CreatedAt sql.NullTime
+	ID        uuid.NullUUID
+}
+
+// Cursors are opaque to clients: base64 of the (created_at, id) keyset of the last chirp on a page.
+func encodeCursor(createdAt time.Time, id uuid.UUID) string {
+	raw := createdAt.UTC().Format(time.RFC3339Nano) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, errors.New("Malformed cursor")
+	}
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return time.Time{}, uuid.Nil, errors.New("Malformed cursor")
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return time.Time{}, uuid.Nil, errors.New("Malformed cursor")
+	}
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return time.Time{}, uuid.Nil, errors.New("Malformed cursor")
+	}
+	return createdAt, id, nil
+}
+
+func parsePageParams(r *http.Request) (pageParams, error) {
+	limit, err := parseLimit(r)
+	if err != nil {
+		return pageParams{}, err
+	}
+	params := pageParams{Limit: limit}
+
+	cursorArg := r.URL.Query().Get("cursor")
+	if cursorArg != "" {
+		createdAt, id, err := decodeCursor(cursorArg)
+		if err != nil {
+			return pageParams{}, err
+		}
+		params.CreatedAt = sql.NullTime{Time: createdAt, Valid: true}
+		params.ID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	return params, nil
+}
+
+func parseLimit(r *http.Request) (int, error) {
+	limitArg := r.URL.Query().Get("limit")
+	if limitArg == "" {
+		return defaultPageSize, nil
+	}
+	limit, err := strconv.Atoi(limitArg)
+	if err != nil || limit < 1 {
+		return 0, errors.New("Invalid limit")
+	}
+	return min(limit, maxPageSize), nil
+}
+
+// Queries fetch one row more than the page size so we know whether another page exists.
+func newChirpsPage(apiChirps []Chirp, limit int) chirpsPage {
+	page := chirpsPage{Chirps: apiChirps}
+	if len(apiChirps) > limit {
+		page.Chirps = apiChirps[:limit]
+		last := page.Chirps[limit-1]
+		page.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+	return page
+}
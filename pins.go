@@ -0,0 +1,118 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/docherak/bd-chirpy/internal/auth"
+	"github.com/docherak/bd-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// Pinning a chirp past the limit unpins the one pinned longest ago.
+const (
+	maxPinnedChirps    = 1
+	maxPinnedChirpsRed = 3
+)
+
+func (cfg *apiConfig) handlerPinsCreate(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't parse UUID", err)
+		return
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error getting bearer token", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	dbChirp, err := cfg.getVisibleChirp(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", err)
+		return
+	}
+
+	if dbChirp.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "Forbidden: You don't own this chirp", err)
+		return
+	}
+
+	if dbChirp.RechirpOf.Valid || dbChirp.Status != chirpStatusPublished {
+		respondWithError(w, http.StatusBadRequest, "Only published chirps can be pinned", nil)
+		return
+	}
+
+	user, err := cfg.db.GetUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	maxPins := maxPinnedChirpsFor(user)
+
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		err := q.PinChirp(r.Context(), database.PinChirpParams{
+			UserID:  userID,
+			ChirpID: chirpID,
+		})
+		if err != nil {
+			return err
+		}
+		return q.TrimPinnedChirps(r.Context(), database.TrimPinnedChirpsParams{
+			UserID:  userID,
+			MaxPins: int32(maxPins),
+		})
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't pin chirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerPinsDelete(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't parse UUID", err)
+		return
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error getting bearer token", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	err = cfg.db.UnpinChirp(r.Context(), database.UnpinChirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unpin chirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// maxPinnedChirpsFor is how many pins a user gets on their current tier. Pins beyond it are
+// kept after Chirpy Red lapses, but only shown again if it's renewed.
+func maxPinnedChirpsFor(user database.User) int {
+	if user.IsChirpyRed {
+		return maxPinnedChirpsRed
+	}
+	return maxPinnedChirps
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/docherak/bd-chirpy/internal/auth"
+	"github.com/docherak/bd-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	minPollOptions      = 2
+	maxPollOptions      = 4
+	maxPollOptionLength = 25
+	minPollDuration     = 5 * time.Minute
+	maxPollDuration     = 7 * 24 * time.Hour
+)
+
+// Poll results are shown to everyone, to those who have voted, or only once the poll is
+// over. The author can always see them.
+const (
+	pollResultsAlways      = "always"
+	pollResultsAfterVote   = "after_vote"
+	pollResultsAfterExpiry = "after_expiry"
+)
+
+// Vote counts are left out while the results are hidden from the viewer.
+type Poll struct {
+	Options   []PollOption `json:"options"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	Expired   bool         `json:"expired"`
+	Results   string       `json:"results"`
+	VoteCount *int64       `json:"vote_count,omitempty"`
+	MyVote    *int32       `json:"my_vote,omitempty"`
+}
+
+type PollOption struct {
+	Text      string `json:"text"`
+	VoteCount *int64 `json:"vote_count,omitempty"`
+}
+
+type pollParameters struct {
+	Options   []string `json:"options"`
+	ExpiresIn int64    `json:"expires_in"`
+	Results   string   `json:"results"`
+}
+
+// validatePoll checks the poll of a new chirp. ExpiresIn is in seconds.
+func validatePoll(params pollParameters) (pollParameters, error) {
+	if len(params.Options) < minPollOptions || len(params.Options) > maxPollOptions {
+		return pollParameters{}, errors.New("Poll needs 2 to 4 options")
+	}
+
+	seen := map[string]bool{}
+	options := []string{}
+	for _, option := range params.Options {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			return pollParameters{}, errors.New("Poll option is empty")
+		}
+		if utf8.RuneCountInString(option) > maxPollOptionLength {
+			return pollParameters{}, errors.New("Poll option is too long")
+		}
+		if seen[strings.ToLower(option)] {
+			return pollParameters{}, errors.New("Duplicate poll option")
+		}
+		seen[strings.ToLower(option)] = true
+		options = append(options, option)
+	}
+
+	duration := time.Duration(params.ExpiresIn) * time.Second
+	if duration < minPollDuration || duration > maxPollDuration {
+		return pollParameters{}, errors.New("Poll must last between 5 minutes and 7 days")
+	}
+
+	results := params.Results
+	if results == "" {
+		results = pollResultsAlways
+	}
+	if results != pollResultsAlways && results != pollResultsAfterVote && results != pollResultsAfterExpiry {
+		return pollParameters{}, errors.New("Unknown poll results setting")
+	}
+
+	return pollParameters{Options: options, ExpiresIn: params.ExpiresIn, Results: results}, nil
+}
+
+func storePoll(ctx context.Context, q *database.Queries, chirp database.Chirp, params pollParameters) error {
+	err := q.CreatePoll(ctx, database.CreatePollParams{
+		ChirpID:   chirp.ID,
+		ExpiresAt: chirp.CreatedAt.Add(time.Duration(params.ExpiresIn) * time.Second),
+		Results:   params.Results,
+	})
+	if err != nil {
+		return err
+	}
+	for i, option := range params.Options {
+		err := q.AddPollOption(ctx, database.AddPollOptionParams{
+			ChirpID:  chirp.ID,
+			Position: int32(i),
+			Text:     option,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cfg *apiConfig) handlerPollVotesCreate(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Option int32 `json:"option"`
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't parse UUID", err)
+		return
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error getting bearer token", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	// Voting through a rechirp counts towards the original.
+	dbChirp, err := cfg.getShareableChirp(r.Context(), userID, chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", err)
+		return
+	}
+
+	poll, err := cfg.db.GetPoll(r.Context(), dbChirp.ID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Chirp has no poll", err)
+		return
+	}
+
+	if !time.Now().UTC().Before(poll.ExpiresAt) {
+		respondWithError(w, http.StatusForbidden, "Poll has expired", nil)
+		return
+	}
+
+	options, err := cfg.db.ListPollOptionsForChirps(r.Context(), []uuid.UUID{dbChirp.ID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get poll", err)
+		return
+	}
+	if params.Option < 0 || int(params.Option) >= len(options) {
+		respondWithError(w, http.StatusBadRequest, "Unknown poll option", nil)
+		return
+	}
+
+	err = cfg.db.CreatePollVote(r.Context(), database.CreatePollVoteParams{
+		ChirpID:  dbChirp.ID,
+		UserID:   userID,
+		Position: params.Option,
+	})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "Already voted", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't vote", err)
+		return
+	}
+
+	apiChirps, err := cfg.databaseChirpsToAPIChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, []database.Chirp{dbChirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirp", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, apiChirps[0])
+}
+
+func (cfg *apiConfig) addPolls(ctx context.Context, viewerID uuid.NullUUID, apiChirps []Chirp) error {
+	ids := chirpIDs(apiChirps)
+	polls, err := cfg.db.ListPollsForChirps(ctx, ids)
+	if err != nil {
+		return err
+	}
+	if len(polls) == 0 {
+		return nil
+	}
+
+	options, err := cfg.db.ListPollOptionsForChirps(ctx, ids)
+	if err != nil {
+		return err
+	}
+	optionsByID := map[uuid.UUID][]database.ListPollOptionsForChirpsRow{}
+	for _, option := range options {
+		optionsByID[option.ChirpID] = append(optionsByID[option.ChirpID], option)
+	}
+
+	myVotes := map[uuid.UUID]int32{}
+	if viewerID.Valid {
+		votes, err := cfg.db.ListPollVotesByUser(ctx, database.ListPollVotesByUserParams{
+			UserID:   viewerID.UUID,
+			ChirpIds: ids,
+		})
+		if err != nil {
+			return err
+		}
+		for _, vote := range votes {
+			myVotes[vote.ChirpID] = vote.Position
+		}
+	}
+
+	pollsByID := map[uuid.UUID]database.Poll{}
+	for _, poll := range polls {
+		pollsByID[poll.ChirpID] = poll
+	}
+
+	now := time.Now().UTC()
+	for i := range apiChirps {
+		dbPoll, ok := pollsByID[apiChirps[i].ID]
+		if !ok || apiChirps[i].IsDeleted {
+			continue
+		}
+
+		poll := &Poll{
+			Options:   []PollOption{},
+			ExpiresAt: dbPoll.ExpiresAt,
+			Expired:   !now.Before(dbPoll.ExpiresAt),
+			Results:   dbPoll.Results,
+		}
+		if myVote, ok := myVotes[dbPoll.ChirpID]; ok {
+			poll.MyVote = &myVote
+		}
+
+		isAuthor := viewerID.Valid && viewerID.UUID == apiChirps[i].UserID
+		showResults := dbPoll.Results == pollResultsAlways ||
+			poll.Expired ||
+			isAuthor ||
+			(dbPoll.Results == pollResultsAfterVote && poll.MyVote != nil)
+
+		var total int64
+		for _, option := range optionsByID[dbPoll.ChirpID] {
+			pollOption := PollOption{Text: option.Text}
+			if showResults {
+				voteCount := option.VoteCount
+				pollOption.VoteCount = &voteCount
+			}
+			total += option.VoteCount
+			poll.Options = append(poll.Options, pollOption)
+		}
+		if showResults {
+			poll.VoteCount = &total
+		}
+		apiChirps[i].Poll = poll
+	}
+	return nil
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/docherak/bd-chirpy/internal/auth"
+	"github.com/docherak/bd-chirpy/internal/chirptext"
+	"github.com/docherak/bd-chirpy/internal/database"
+	"github.com/docherak/bd-chirpy/internal/entities"
+	"github.com/google/uuid"
+)
+
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+	maxWebsiteLength     = 200
+	maxLocationLength    = 30
+)
+
+// Profile is what anyone can see about a user. Unlike User it never carries the email address.
+type Profile struct {
+	ID          uuid.UUID   `json:"id"`
+	CreatedAt   time.Time   `json:"created_at"`
+	Handle      string      `json:"handle"`
+	DisplayName string      `json:"display_name"`
+	Bio         string      `json:"bio"`
+	Website     string      `json:"website"`
+	Location    string      `json:"location"`
+	Avatar      *Attachment `json:"avatar"`
+	IsChirpyRed bool        `json:"is_chirpy_red"`
+}
+
+// handlerProfilesGet looks a user up by ID or handle. Users the viewer can't see, because
+// they are suspended, shadowbanned or have blocked the viewer, are reported as not found.
+func (cfg *apiConfig) handlerProfilesGet(w http.ResponseWriter, r *http.Request) {
+	userID, err := cfg.resolveUserRef(r.Context(), r.PathValue("userRef"))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+
+	viewerID := cfg.getViewerID(r)
+	if !viewerID.Valid || viewerID.UUID != userID {
+		visible, err := cfg.db.IsUserVisibleTo(r.Context(), database.IsUserVisibleToParams{
+			UserID:   userID,
+			ViewerID: viewerID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+			return
+		}
+		if !visible {
+			respondWithError(w, http.StatusNotFound, "User not found", nil)
+			return
+		}
+	}
+
+	user, err := cfg.db.GetUser(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+
+	profile, err := cfg.databaseUserToProfile(r.Context(), user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get avatar", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, profile)
+}
+
+// handlerProfilesUpdate changes the caller's profile. Fields left out of the request keep
+// their current value; an empty string clears everything but the handle.
+func (cfg *apiConfig) handlerProfilesUpdate(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Handle      *string `json:"handle"`
+		DisplayName *string `json:"display_name"`
+		Bio         *string `json:"bio"`
+		Website     *string `json:"website"`
+		Location    *string `json:"location"`
+		AvatarID    *string `json:"avatar_id"`
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error getting bearer token", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	// Only the fields in the request are written, so concurrent updates to different fields
+	// don't undo each other.
+	update := database.UpdateUserProfileParams{ID: userID}
+	if params.Handle != nil {
+		if !entities.IsValidHandle(*params.Handle) {
+			respondWithError(w, http.StatusBadRequest, "Invalid handle", nil)
+			return
+		}
+		update.Handle = sql.NullString{String: *params.Handle, Valid: true}
+	}
+	if params.DisplayName != nil {
+		displayName, err := cfg.validateDisplayName(*params.DisplayName)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		update.DisplayName = sql.NullString{String: displayName, Valid: true}
+	}
+	if params.Bio != nil {
+		bio, err := cfg.validateBio(*params.Bio)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		update.Bio = sql.NullString{String: bio, Valid: true}
+	}
+	if params.Website != nil {
+		website, err := validateWebsite(*params.Website)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		update.Website = sql.NullString{String: website, Valid: true}
+	}
+	if params.Location != nil {
+		location, err := validateLocation(*params.Location)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		update.Location = sql.NullString{String: location, Valid: true}
+	}
+	if params.AvatarID != nil {
+		update.SetAvatar = true
+		update.AvatarMediaID, err = cfg.validateAvatar(r.Context(), userID, *params.AvatarID)
+		if errors.Is(err, errMediaUnavailable) {
+			respondWithError(w, http.StatusBadRequest, "Media not found or already attached", err)
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Couldn't parse avatar_id", err)
+			return
+		}
+	}
+
+	user, err := cfg.db.UpdateUserProfile(r.Context(), update)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't get user", err)
+		return
+	}
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "Handle is already taken", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update profile", err)
+		return
+	}
+
+	profile, err := cfg.databaseUserToProfile(r.Context(), user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get avatar", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, profile)
+}
+
+// validateDisplayName checks a display name, which is a single line. Like the bio it goes
+// through the moderation filters.
+func (cfg *apiConfig) validateDisplayName(displayName string) (string, error) {
+	displayName = strings.TrimSpace(chirptext.Normalize(displayName))
+	if strings.ContainsAny(displayName, "\n\t") {
+		return "", errors.New("Display name must be a single line")
+	}
+	if chirptext.Length(displayName, 0) > maxDisplayNameLength {
+		return "", errors.New("Display name is too long")
+	}
+	return cfg.moderateProfileText(displayName, "Display name")
+}
+
+// validateBio checks a bio. Links count the same as in chirps.
+func (cfg *apiConfig) validateBio(bio string) (string, error) {
+	bio = strings.TrimSpace(chirptext.Normalize(bio))
+	if chirptext.Length(bio, cfg.chirpURLLength) > maxBioLength {
+		return "", errors.New("Bio is too long")
+	}
+	return cfg.moderateProfileText(bio, "Bio")
+}
+
+// moderateProfileText masks or rejects profile text like a chirp. Flags are tied to chirps,
+// so flagging rules don't apply here.
+func (cfg *apiConfig) moderateProfileText(text, field string) (string, error) {
+	result := cfg.moderator.Check(text)
+	if result.Rejected() {
+		return "", errors.New(field + " contains language that isn't allowed")
+	}
+	return result.Text, nil
+}
+
+func validateWebsite(website string) (string, error) {
+	website = strings.TrimSpace(website)
+	if website == "" {
+		return "", nil
+	}
+	if len(website) > maxWebsiteLength {
+		return "", errors.New("Website is too long")
+	}
+	u, err := url.Parse(website)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", errors.New("Website must be an http or https URL")
+	}
+	return u.String(), nil
+}
+
+func validateLocation(location string) (string, error) {
+	location = strings.TrimSpace(chirptext.Normalize(location))
+	if strings.ContainsAny(location, "\n\t") {
+		return "", errors.New("Location must be a single line")
+	}
+	if chirptext.Length(location, 0) > maxLocationLength {
+		return "", errors.New("Location is too long")
+	}
+	return location, nil
+}
+
+// validateAvatar checks that an upload can be used as the user's avatar: it has to be theirs
+// and not part of a chirp. An empty ID removes the avatar.
+func (cfg *apiConfig) validateAvatar(ctx context.Context, userID uuid.UUID, avatarID string) (uuid.NullUUID, error) {
+	if avatarID == "" {
+		return uuid.NullUUID{}, nil
+	}
+	mediaID, err := uuid.Parse(avatarID)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	media, err := cfg.db.GetMedia(ctx, mediaID)
+	if err != nil || media.UserID != userID || media.ChirpID.Valid {
+		return uuid.NullUUID{}, errMediaUnavailable
+	}
+	return uuid.NullUUID{UUID: mediaID, Valid: true}, nil
+}
+
+func (cfg *apiConfig) databaseUserToProfile(ctx context.Context, dbUser database.User) (Profile, error) {
+	profile := Profile{
+		ID:          dbUser.ID,
+		CreatedAt:   dbUser.CreatedAt,
+		Handle:      dbUser.Handle.String,
+		DisplayName: dbUser.DisplayName,
+		Bio:         dbUser.Bio,
+		Website:     dbUser.Website,
+		Location:    dbUser.Location,
+		IsChirpyRed: dbUser.IsChirpyRed,
+	}
+	if !dbUser.AvatarMediaID.Valid {
+		return profile, nil
+	}
+	media, err := cfg.db.GetMedia(ctx, dbUser.AvatarMediaID.UUID)
+	if err != nil {
+		return Profile{}, err
+	}
+	variantsByID, err := cfg.getMediaVariants(ctx, []database.Medium{media})
+	if err != nil {
+		return Profile{}, err
+	}
+	avatar := cfg.databaseMediaToAttachment(media, variantsByID[media.ID])
+	profile.Avatar = &avatar
+	return profile, nil
+}
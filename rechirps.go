@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+
+	"github.com/docherak/bd-chirpy/internal/auth"
+	"github.com/docherak/bd-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) handlerRechirpsCreate(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't parse UUID", err)
+		return
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error getting bearer token", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	original, err := cfg.getShareableChirp(r.Context(), userID, chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", err)
+		return
+	}
+
+	// A rechirp shows the chirp to everyone who sees the rechirp, so only public ones qualify.
+	if original.Visibility != chirpVisibilityPublic {
+		respondWithError(w, http.StatusBadRequest, "Only public chirps can be rechirped", nil)
+		return
+	}
+
+	err = cfg.db.CreateRechirp(r.Context(), database.CreateRechirpParams{
+		UserID:    userID,
+		RechirpOf: uuid.NullUUID{UUID: original.ID, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't rechirp chirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerRechirpsDelete(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't parse UUID", err)
+		return
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error getting bearer token", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	err = cfg.db.DeleteRechirp(r.Context(), database.DeleteRechirpParams{
+		UserID:    userID,
+		RechirpOf: uuid.NullUUID{UUID: chirpID, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't undo rechirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getShareableChirp looks up a chirp that is about to be replied to, quoted or rechirped.
+// Rechirps resolve to the chirp they share, so nothing ever points at an empty rechirp.
+// Only published chirps the user can see can be shared.
+func (cfg *apiConfig) getShareableChirp(ctx context.Context, userID, chirpID uuid.UUID) (database.Chirp, error) {
+	viewerID := uuid.NullUUID{UUID: userID, Valid: true}
+	dbChirp, err := cfg.getVisibleChirp(ctx, viewerID, chirpID)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if dbChirp.Status != chirpStatusPublished {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	if dbChirp.RechirpOf.Valid {
+		dbChirp, err = cfg.getVisibleChirp(ctx, viewerID, dbChirp.RechirpOf.UUID)
+		if err != nil {
+			return database.Chirp{}, err
+		}
+	}
+	return dbChirp, nil
+}
+
+func (cfg *apiConfig) addShareCounts(ctx context.Context, viewerID uuid.NullUUID, apiChirps []Chirp) error {
+	ids := chirpIDs(apiChirps)
+	rechirpCounts, err := cfg.db.CountRechirpsForChirps(ctx, database.CountRechirpsForChirpsParams{
+		ChirpIds: ids,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		return err
+	}
+	rechirpCountByID := map[uuid.UUID]int64{}
+	for _, row := range rechirpCounts {
+		rechirpCountByID[row.RechirpOf.UUID] = row.RechirpCount
+	}
+
+	quoteCounts, err := cfg.db.CountQuotesForChirps(ctx, database.CountQuotesForChirpsParams{
+		ChirpIds: ids,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		return err
+	}
+	quoteCountByID := map[uuid.UUID]int64{}
+	for _, row := range quoteCounts {
+		quoteCountByID[row.QuoteOf.UUID] = row.QuoteCount
+	}
+
+	for i := range apiChirps {
+		apiChirps[i].RechirpCount = rechirpCountByID[apiChirps[i].ID]
+		apiChirps[i].QuoteCount = quoteCountByID[apiChirps[i].ID]
+	}
+	return nil
+}
+
+// embedSharedChirps attaches a copy of the rechirped or quoted chirp. Embedded chirps
+// don't embed further, so a quote of a quote only shows one level.
+func (cfg *apiConfig) embedSharedChirps(ctx context.Context, viewerID uuid.NullUUID, dbChirps []database.Chirp, apiChirps []Chirp) error {
+	sharedIDs := []uuid.UUID{}
+	for _, dbChirp := range dbChirps {
+		if dbChirp.RechirpOf.Valid {
+			sharedIDs = append(sharedIDs, dbChirp.RechirpOf.UUID)
+		}
+		if dbChirp.QuoteOf.Valid {
+			sharedIDs = append(sharedIDs, dbChirp.QuoteOf.UUID)
+		}
+	}
+	if len(sharedIDs) == 0 {
+		return nil
+	}
+
+	// Quoted chirps the viewer can't see are left out, as if they were gone. Rechirps of them
+	// never get here, chirp_visible_to hides a rechirp along with the chirp it shares.
+	dbShared, err := cfg.db.GetChirpsByIDs(ctx, database.GetChirpsByIDsParams{
+		ChirpIds: sharedIDs,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		return err
+	}
+	apiShared, err := cfg.decorateChirps(ctx, viewerID, dbShared)
+	if err != nil {
+		return err
+	}
+	sharedByID := map[uuid.UUID]*Chirp{}
+	for i := range apiShared {
+		sharedByID[apiShared[i].ID] = &apiShared[i]
+	}
+
+	for i, dbChirp := range dbChirps {
+		if dbChirp.RechirpOf.Valid {
+			apiChirps[i].RechirpOf = sharedByID[dbChirp.RechirpOf.UUID]
+		}
+		if dbChirp.QuoteOf.Valid {
+			apiChirps[i].QuoteOf = sharedByID[dbChirp.QuoteOf.UUID]
+		}
+	}
+	return nil
+}
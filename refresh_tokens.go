@@ -24,6 +24,11 @@ func (cfg *apiConfig) handlerTokenRefresh(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if isSuspended(user) {
+		respondWithError(w, http.StatusForbidden, "Account suspended", nil)
+		return
+	}
+
 	accessToken, err := auth.MakeJWT(
 		user.ID,
 		cfg.jwtSecret,
@@ -0,0 +1,428 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/docherak/bd-chirpy/internal/auth"
+	"github.com/docherak/bd-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	reportStatusOpen     = "open"
+	reportStatusClaimed  = "claimed"
+	reportStatusResolved = "resolved"
+)
+
+const (
+	decisionClaim         = "claim"
+	decisionDismiss       = "dismiss"
+	decisionDeleteChirp   = "delete_chirp"
+	decisionSuspendAuthor = "suspend_author"
+)
+
+var errNoReportedChirp = errors.New("report has no chirp")
+
+var reportReasons = map[string]struct{}{
+	"spam":          {},
+	"harassment":    {},
+	"hate":          {},
+	"violence":      {},
+	"sexual":        {},
+	"self_harm":     {},
+	"impersonation": {},
+	"other":         {},
+}
+
+type Report struct {
+	ID         uuid.UUID            `json:"id"`
+	CreatedAt  time.Time            `json:"created_at"`
+	UpdatedAt  time.Time            `json:"updated_at"`
+	ReporterID uuid.UUID            `json:"reporter_id"`
+	UserID     uuid.UUID            `json:"user_id"`
+	ChirpID    *uuid.UUID           `json:"chirp_id,omitempty"`
+	Reason     string               `json:"reason"`
+	Details    string               `json:"details,omitempty"`
+	Status     string               `json:"status"`
+	ClaimedBy  *uuid.UUID           `json:"claimed_by,omitempty"`
+	Resolution string               `json:"resolution,omitempty"`
+	Decisions  []ModerationDecision `json:"decisions,omitempty"`
+}
+
+type ModerationDecision struct {
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ModeratorID *uuid.UUID `json:"moderator_id,omitempty"`
+	Action      string     `json:"action"`
+	Note        string     `json:"note,omitempty"`
+}
+
+type reportsPage struct {
+	Reports    []Report `json:"reports"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+func (cfg *apiConfig) handlerReportsCreate(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		ChirpID *uuid.UUID `json:"chirp_id"`
+		UserID  *uuid.UUID `json:"user_id"`
+		Reason  string     `json:"reason"`
+		Details string     `json:"details"`
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error getting bearer token", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	if (params.ChirpID == nil) == (params.UserID == nil) {
+		respondWithError(w, http.StatusBadRequest, "Report either a chirp or a user", nil)
+		return
+	}
+	if _, ok := reportReasons[params.Reason]; !ok {
+		respondWithError(w, http.StatusBadRequest, "Unknown reason", nil)
+		return
+	}
+	const maxDetailsLength = 500
+	if utf8.RuneCountInString(params.Details) > maxDetailsLength {
+		respondWithError(w, http.StatusBadRequest, "Details are too long", nil)
+		return
+	}
+
+	// A chirp report also names the author, so moderators can act on the account.
+	reportParams := database.CreateReportParams{
+		ReporterID: userID,
+		Reason:     params.Reason,
+		Details:    params.Details,
+	}
+	if params.ChirpID != nil {
+		dbChirp, err := cfg.getVisibleChirp(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, *params.ChirpID)
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "Chirp not found", err)
+			return
+		}
+		reportParams.UserID = dbChirp.UserID
+		reportParams.ChirpID = uuid.NullUUID{UUID: dbChirp.ID, Valid: true}
+	} else {
+		dbUser, err := cfg.db.GetUser(r.Context(), *params.UserID)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "User not found", err)
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+			return
+		}
+		reportParams.UserID = dbUser.ID
+	}
+
+	if reportParams.UserID == userID {
+		respondWithError(w, http.StatusBadRequest, "You can't report yourself", nil)
+		return
+	}
+
+	dbReport, err := cfg.db.CreateReport(r.Context(), reportParams)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create report", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, databaseReportToAPIReport(dbReport))
+}
+
+func (cfg *apiConfig) handlerReportsGet(w http.ResponseWriter, r *http.Request) {
+	if _, ok := cfg.requireModerator(w, r); !ok {
+		return
+	}
+
+	// Without a status, the queue holds everything that still needs a decision.
+	status := sql.NullString{}
+	statusArg := r.URL.Query().Get("status")
+	switch statusArg {
+	case "":
+	case reportStatusOpen, reportStatusClaimed, reportStatusResolved:
+		status = sql.NullString{String: statusArg, Valid: true}
+	default:
+		respondWithError(w, http.StatusBadRequest, "Invalid status", nil)
+		return
+	}
+
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	// The queue is worked oldest first, so the cursor moves forward in time.
+	dbReports, err := cfg.db.ListReports(r.Context(), database.ListReportsParams{
+		Status:          status,
+		CursorCreatedAt: page.CreatedAt,
+		CursorID:        page.ID,
+		PageSize:        int32(page.Limit + 1),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting reports", err)
+		return
+	}
+
+	reports := reportsPage{Reports: []Report{}}
+	for _, dbReport := range dbReports {
+		reports.Reports = append(reports.Reports, databaseReportToAPIReport(dbReport))
+	}
+	if len(reports.Reports) > page.Limit {
+		reports.Reports = reports.Reports[:page.Limit]
+		last := reports.Reports[page.Limit-1]
+		reports.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	respondWithJSON(w, http.StatusOK, reports)
+}
+
+func (cfg *apiConfig) handlerReportsGetSingle(w http.ResponseWriter, r *http.Request) {
+	reportID, err := uuid.Parse(r.PathValue("reportID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't parse UUID", err)
+		return
+	}
+
+	if _, ok := cfg.requireModerator(w, r); !ok {
+		return
+	}
+
+	dbReport, err := cfg.db.GetReport(r.Context(), reportID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Report not found", err)
+		return
+	}
+
+	dbDecisions, err := cfg.db.ListReportDecisions(r.Context(), uuid.NullUUID{UUID: reportID, Valid: true})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting report history", err)
+		return
+	}
+
+	report := databaseReportToAPIReport(dbReport)
+	report.Decisions = []ModerationDecision{}
+	for _, dbDecision := range dbDecisions {
+		decision := ModerationDecision{
+			ID:        dbDecision.ID,
+			CreatedAt: dbDecision.CreatedAt,
+			Action:    dbDecision.Action,
+			Note:      dbDecision.Note,
+		}
+		if dbDecision.ModeratorID.Valid {
+			decision.ModeratorID = &dbDecision.ModeratorID.UUID
+		}
+		report.Decisions = append(report.Decisions, decision)
+	}
+
+	respondWithJSON(w, http.StatusOK, report)
+}
+
+// handlerReportsClaim assigns a report to the calling moderator so two people don't work on
+// it at once.
+func (cfg *apiConfig) handlerReportsClaim(w http.ResponseWriter, r *http.Request) {
+	reportID, err := uuid.Parse(r.PathValue("reportID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't parse UUID", err)
+		return
+	}
+
+	moderatorID, ok := cfg.requireModerator(w, r)
+	if !ok {
+		return
+	}
+
+	var dbReport database.Report
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		dbReport, err = q.ClaimReport(r.Context(), database.ClaimReportParams{
+			ModeratorID: uuid.NullUUID{UUID: moderatorID, Valid: true},
+			ID:          reportID,
+		})
+		if err != nil {
+			return err
+		}
+		return q.CreateModerationDecision(r.Context(), database.CreateModerationDecisionParams{
+			ModeratorID: uuid.NullUUID{UUID: moderatorID, Valid: true},
+			ReportID:    uuid.NullUUID{UUID: reportID, Valid: true},
+			Action:      decisionClaim,
+			UserID:      uuid.NullUUID{UUID: dbReport.UserID, Valid: true},
+			ChirpID:     dbReport.ChirpID,
+		})
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		cfg.respondWithUnavailableReport(w, r, reportID)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't claim report", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, databaseReportToAPIReport(dbReport))
+}
+
+// handlerReportsResolve closes a report with a decision, carrying it out in the same
+// transaction so the audit trail never records something that didn't happen.
+func (cfg *apiConfig) handlerReportsResolve(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Action   string `json:"action"`
+		Duration string `json:"duration"`
+		Note     string `json:"note"`
+	}
+
+	reportID, err := uuid.Parse(r.PathValue("reportID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't parse UUID", err)
+		return
+	}
+
+	moderatorID, ok := cfg.requireModerator(w, r)
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	switch params.Action {
+	case decisionDismiss, decisionDeleteChirp, decisionSuspendAuthor:
+	default:
+		respondWithError(w, http.StatusBadRequest, "Unknown action", nil)
+		return
+	}
+
+	// Suspensions from the queue may be time-limited, like the ones handed out directly.
+	suspendUntil, err := parseSuspensionDuration(params.Duration)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	var dbReport database.Report
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		dbReport, err = q.ResolveReport(r.Context(), database.ResolveReportParams{
+			ModeratorID: uuid.NullUUID{UUID: moderatorID, Valid: true},
+			Resolution:  sql.NullString{String: params.Action, Valid: true},
+			ID:          reportID,
+		})
+		if err != nil {
+			return err
+		}
+
+		switch params.Action {
+		case decisionDeleteChirp:
+			if !dbReport.ChirpID.Valid {
+				return errNoReportedChirp
+			}
+			err = removeChirp(r.Context(), q, dbReport.ChirpID.UUID)
+		case decisionSuspendAuthor:
+			err = suspendUser(r.Context(), q, dbReport.UserID, suspendUntil)
+		}
+		if err != nil {
+			return err
+		}
+
+		return q.CreateModerationDecision(r.Context(), database.CreateModerationDecisionParams{
+			ModeratorID: uuid.NullUUID{UUID: moderatorID, Valid: true},
+			ReportID:    uuid.NullUUID{UUID: reportID, Valid: true},
+			Action:      params.Action,
+			UserID:      uuid.NullUUID{UUID: dbReport.UserID, Valid: true},
+			ChirpID:     dbReport.ChirpID,
+			Note:        params.Note,
+		})
+	})
+	if errors.Is(err, errNoReportedChirp) {
+		respondWithError(w, http.StatusBadRequest, "Report has no chirp to delete", err)
+		return
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		cfg.respondWithUnavailableReport(w, r, reportID)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't resolve report", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, databaseReportToAPIReport(dbReport))
+}
+
+// removeChirp takes a chirp down for a moderator. It becomes a tombstone straight away, like
+// one whose time in the trash is up, so replies keep their thread but the author can't restore
+// it. Its edit history goes with it.
+func removeChirp(ctx context.Context, q *database.Queries, chirpID uuid.UUID) error {
+	err := q.SoftDeleteRechirpsOf(ctx, uuid.NullUUID{UUID: chirpID, Valid: true})
+	if err != nil {
+		return err
+	}
+	err = q.ScrubChirp(ctx, chirpID)
+	if err != nil {
+		return err
+	}
+	return q.DeleteChirpRevisions(ctx, chirpID)
+}
+
+// respondWithUnavailableReport explains why a report couldn't be claimed or resolved: it
+// doesn't exist, it's already resolved, or another moderator has it.
+func (cfg *apiConfig) respondWithUnavailableReport(w http.ResponseWriter, r *http.Request, reportID uuid.UUID) {
+	dbReport, err := cfg.db.GetReport(r.Context(), reportID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Report not found", err)
+		return
+	}
+	if dbReport.Status == reportStatusResolved {
+		respondWithError(w, http.StatusConflict, "Report is already resolved", nil)
+		return
+	}
+	respondWithError(w, http.StatusConflict, "Report is claimed by another moderator", nil)
+}
+
+func databaseReportToAPIReport(dbReport database.Report) Report {
+	report := Report{
+		ID:         dbReport.ID,
+		CreatedAt:  dbReport.CreatedAt,
+		UpdatedAt:  dbReport.UpdatedAt,
+		ReporterID: dbReport.ReporterID,
+		UserID:     dbReport.UserID,
+		Reason:     dbReport.Reason,
+		Details:    dbReport.Details,
+		Status:     dbReport.Status,
+		Resolution: dbReport.Resolution.String,
+	}
+	if dbReport.ChirpID.Valid {
+		report.ChirpID = &dbReport.ChirpID.UUID
+	}
+	if dbReport.ClaimedBy.Valid {
+		report.ClaimedBy = &dbReport.ClaimedBy.UUID
+	}
+	return report
+}
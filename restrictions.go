@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/docherak/bd-chirpy/internal/auth"
+	"github.com/docherak/bd-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	decisionSuspend     = "suspend"
+	decisionUnsuspend   = "unsuspend"
+	decisionShadowban   = "shadowban"
+	decisionUnshadowban = "unshadowban"
+)
+
+// isSuspended reports whether a user is currently suspended. Time-limited suspensions end on
+// their own, even before the worker gets around to clearing them.
+func isSuspended(user database.User) bool {
+	if !user.SuspendedAt.Valid {
+		return false
+	}
+	return !user.SuspendedUntil.Valid || user.SuspendedUntil.Time.After(time.Now().UTC())
+}
+
+// parseSuspensionDuration turns an optional duration such as "72h" into the end of a
+// suspension. Without one, the suspension lasts until it is lifted.
+func parseSuspensionDuration(duration string) (sql.NullTime, error) {
+	if duration == "" {
+		return sql.NullTime{}, nil
+	}
+	d, err := time.ParseDuration(duration)
+	if err != nil || d <= 0 {
+		return sql.NullTime{}, errors.New("Duration must be positive, such as \"72h\"")
+	}
+	return sql.NullTime{Time: time.Now().UTC().Add(d), Valid: true}, nil
+}
+
+// suspendUser suspends a user and signs them out everywhere. Access tokens they still hold
+// are turned away by middlewareRejectSuspended.
+func suspendUser(ctx context.Context, q *database.Queries, userID uuid.UUID, until sql.NullTime) error {
+	err := q.SuspendUser(ctx, database.SuspendUserParams{
+		ID:             userID,
+		SuspendedUntil: until,
+	})
+	if err != nil {
+		return err
+	}
+	tokens, err := q.ListActiveRefreshTokens(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, token := range tokens {
+		_, err := q.RevokeRefreshToken(ctx, token)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// middlewareRejectSuspended turns away requests made with a suspended user's access token.
+// Requests without a valid JWT pass through, so anonymous endpoints, refresh tokens and
+// webhooks are left to the handlers.
+func (cfg *apiConfig) middlewareRejectSuspended(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bearerToken, err := auth.GetBearerToken(r.Header)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		user, err := cfg.db.GetUser(r.Context(), userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+			return
+		}
+		if isSuspended(user) {
+			respondWithError(w, http.StatusForbidden, "Account suspended", nil)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// liftExpiredSuspensions clears time-limited suspensions that have run out.
+func (cfg *apiConfig) liftExpiredSuspensions(ctx context.Context) error {
+	lifted, err := cfg.db.LiftExpiredSuspensions(ctx)
+	if err != nil {
+		return err
+	}
+	if lifted > 0 {
+		log.Printf("Lifted %d expired suspensions", lifted)
+	}
+	return nil
+}
+
+func (cfg *apiConfig) handlerUserSuspend(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Duration string `json:"duration"`
+		Note     string `json:"note"`
+	}
+
+	userID, moderatorID, ok := cfg.getRestrictableUser(w, r)
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	until, err := parseSuspensionDuration(params.Duration)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		err := suspendUser(r.Context(), q, userID, until)
+		if err != nil {
+			return err
+		}
+		return recordUserDecision(r.Context(), q, moderatorID, userID, decisionSuspend, params.Note)
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't suspend user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUserUnsuspend(w http.ResponseWriter, r *http.Request) {
+	userID, moderatorID, ok := cfg.getRestrictableUser(w, r)
+	if !ok {
+		return
+	}
+
+	err := cfg.withTx(r.Context(), func(q *database.Queries) error {
+		err := q.LiftSuspension(r.Context(), userID)
+		if err != nil {
+			return err
+		}
+		return recordUserDecision(r.Context(), q, moderatorID, userID, decisionUnsuspend, "")
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't lift suspension", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUserShadowban(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Note string `json:"note"`
+	}
+
+	userID, moderatorID, ok := cfg.getRestrictableUser(w, r)
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		err := q.ShadowbanUser(r.Context(), userID)
+		if err != nil {
+			return err
+		}
+		return recordUserDecision(r.Context(), q, moderatorID, userID, decisionShadowban, params.Note)
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't shadowban user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUserUnshadowban(w http.ResponseWriter, r *http.Request) {
+	userID, moderatorID, ok := cfg.getRestrictableUser(w, r)
+	if !ok {
+		return
+	}
+
+	err := cfg.withTx(r.Context(), func(q *database.Queries) error {
+		err := q.LiftShadowban(r.Context(), userID)
+		if err != nil {
+			return err
+		}
+		return recordUserDecision(r.Context(), q, moderatorID, userID, decisionUnshadowban, "")
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't lift shadowban", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getRestrictableUser checks that a moderator is calling and looks up the user in the path.
+// Moderators can't restrict themselves, so nobody locks themselves out by accident.
+func (cfg *apiConfig) getRestrictableUser(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't parse UUID", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	moderatorID, ok := cfg.requireModerator(w, r)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	if userID == moderatorID {
+		respondWithError(w, http.StatusBadRequest, "You can't restrict yourself", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	_, err = cfg.db.GetUser(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "User not found", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, moderatorID, true
+}
+
+func recordUserDecision(ctx context.Context, q *database.Queries, moderatorID, userID uuid.UUID, action, note string) error {
+	return q.CreateModerationDecision(ctx, database.CreateModerationDecisionParams{
+		ModeratorID: uuid.NullUUID{UUID: moderatorID, Valid: true},
+		Action:      action,
+		UserID:      uuid.NullUUID{UUID: userID, Valid: true},
+		Note:        note,
+	})
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/docherak/bd-chirpy/internal/database"
+	"github.com/docherak/bd-chirpy/internal/search"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) handlerChirpsSearch(w http.ResponseWriter, r *http.Request) {
+	query, err := search.ParseQuery(r.URL.Query().Get("q"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	sortArg := r.URL.Query().Get("sort")
+	if sortArg != "relevance" && sortArg != "recent" {
+		sortArg = "relevance"
+	}
+	// Ranking needs something to rank against.
+	if query.Text == "" {
+		sortArg = "recent"
+	}
+
+	authorID := uuid.NullUUID{}
+	if query.From != "" {
+		userID, err := cfg.resolveUserRef(r.Context(), query.From)
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "User not found", err)
+			return
+		}
+		authorID = uuid.NullUUID{UUID: userID, Valid: true}
+	}
+
+	since := sql.NullTime{Time: query.Since, Valid: !query.Since.IsZero()}
+	until := sql.NullTime{Time: query.Until, Valid: !query.Until.IsZero()}
+	viewerID := cfg.getViewerID(r)
+
+	if sortArg == "recent" {
+		page, err := parsePageParams(r)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+
+		dbChirps, err := cfg.db.SearchChirpsByRecency(r.Context(), database.SearchChirpsByRecencyParams{
+			Query:           query.Text,
+			AuthorID:        authorID,
+			Since:           since,
+			Until:           until,
+			CursorCreatedAt: page.CreatedAt,
+			CursorID:        page.ID,
+			ViewerID:        viewerID,
+			PageSize:        int32(page.Limit + 1),
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error searching chirps", err)
+			return
+		}
+
+		apiChirps, err := cfg.databaseChirpsToAPIChirps(r.Context(), viewerID, dbChirps)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Error searching chirps", err)
+			return
+		}
+
+		respondWithJSON(w, http.StatusOK, newChirpsPage(apiChirps, page.Limit))
+		return
+	}
+
+	page, err := parseRankPageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	rows, err := cfg.db.SearchChirpsByRelevance(r.Context(), database.SearchChirpsByRelevanceParams{
+		Query:      query.Text,
+		AuthorID:   authorID,
+		Since:      since,
+		Until:      until,
+		CursorRank: page.Rank,
+		CursorID:   page.ID,
+		ViewerID:   viewerID,
+		PageSize:   int32(page.Limit + 1),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error searching chirps", err)
+		return
+	}
+
+	dbChirps := []database.Chirp{}
+	for _, row := range rows {
+		dbChirps = append(dbChirps, row.Chirp)
+	}
+
+	apiChirps, err := cfg.databaseChirpsToAPIChirps(r.Context(), viewerID, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error searching chirps", err)
+		return
+	}
+
+	chirps := newChirpsPage(apiChirps, page.Limit)
+	if chirps.NextCursor != "" {
+		last := rows[page.Limit-1]
+		chirps.NextCursor = encodeRankCursor(last.Rank, last.Chirp.ID)
+	}
+
+	respondWithJSON(w, http.StatusOK, chirps)
+}
+
+type rankPageParams struct {
+	Limit int
+	Rank  sql.NullFloat64
+	ID    uuid.NullUUID
+}
+
+// Relevance-ordered results are keyed on (rank, id) instead of (created_at, id).
+func encodeRankCursor(rank float32, id uuid.UUID) string {
+	raw := strconv.FormatFloat(float64(rank), 'g', -1, 32) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func parseRankPageParams(r *http.Request) (rankPageParams, error) {
+	limit, err := parseLimit(r)
+	if err != nil {
+		return rankPageParams{}, err
+	}
+	params := rankPageParams{Limit: limit}
+
+	cursorArg := r.URL.Query().Get("cursor")
+	if cursorArg == "" {
+		return params, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursorArg)
+	if err != nil {
+		return rankPageParams{}, errors.New("Malformed cursor")
+	}
+	rankString, idString, found := strings.Cut(string(raw), "|")
+	if !found {
+		return rankPageParams{}, errors.New("Malformed cursor")
+	}
+	rank, err := strconv.ParseFloat(rankString, 32)
+	if err != nil {
+		return rankPageParams{}, errors.New("Malformed cursor")
+	}
+	id, err := uuid.Parse(idString)
+	if err != nil {
+		return rankPageParams{}, errors.New("Malformed cursor")
+	}
+	params.Rank = sql.NullFloat64{Float64: rank, Valid: true}
+	params.ID = uuid.NullUUID{UUID: id, Valid: true}
+	return params, nil
+}
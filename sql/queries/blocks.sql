@@ -0,0 +1,52 @@
+-- name: BlockUser :exec
+INSERT INTO blocks (user_id, blocked_id, created_at)
+VALUES (
+    $1, $2, NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: UnblockUser :exec
+DELETE FROM blocks
+WHERE user_id = $1 AND blocked_id = $2;
+
+-- name: IsBlocked :one
+SELECT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE user_id = $1 AND blocked_id = $2
+);
+
+-- name: ListBlockersAmong :many
+SELECT user_id FROM blocks
+WHERE blocked_id = sqlc.arg('blocked_id')
+AND user_id = ANY(sqlc.arg('user_ids')::uuid[]);
+
+-- name: ListBlockedUsers :many
+SELECT blocked_id AS user_id, created_at FROM blocks
+WHERE user_id = sqlc.arg('user_id')
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, blocked_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at DESC, blocked_id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: MuteUser :exec
+INSERT INTO mutes (user_id, muted_id, created_at)
+VALUES (
+    $1, $2, NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: UnmuteUser :exec
+DELETE FROM mutes
+WHERE user_id = $1 AND muted_id = $2;
+
+-- name: ListMutedUsers :many
+SELECT muted_id AS user_id, created_at FROM mutes
+WHERE user_id = sqlc.arg('user_id')
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, muted_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at DESC, muted_id DESC
+LIMIT sqlc.arg('page_size');
@@ -0,0 +1,29 @@
+-- name: BookmarkChirp :exec
+INSERT INTO bookmarks (user_id, chirp_id, created_at)
+VALUES (
+    $1, $2, NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: UnbookmarkChirp :exec
+DELETE FROM bookmarks
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: ListBookmarkedChirpIDs :many
+SELECT chirp_id FROM bookmarks
+WHERE user_id = sqlc.arg('user_id')
+AND chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: ListBookmarkedChirps :many
+SELECT sqlc.embed(chirps), bookmarks.created_at AS bookmarked_at FROM bookmarks
+JOIN chirps ON chirps.id = bookmarks.chirp_id
+WHERE bookmarks.user_id = sqlc.arg('user_id')
+AND chirps.deleted_at IS NULL
+AND chirps.status = 'published'
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (bookmarks.created_at, bookmarks.chirp_id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.arg('user_id'))
+ORDER BY bookmarks.created_at DESC, bookmarks.chirp_id DESC
+LIMIT sqlc.arg('page_size');
@@ -0,0 +1,20 @@
+-- name: CreateChirpRevision :exec
+INSERT INTO chirp_revisions (id, chirp_id, body, created_at, replaced_at)
+VALUES (
+    gen_random_uuid(), $1, $2, $3, NOW()
+);
+
+-- name: ListChirpRevisions :many
+SELECT * FROM chirp_revisions
+WHERE chirp_id = $1
+ORDER BY replaced_at DESC;
+
+-- name: DeleteChirpRevisions :exec
+DELETE FROM chirp_revisions
+WHERE chirp_id = $1;
+
+-- name: DeleteRevisionsOfDeletedChirps :exec
+DELETE FROM chirp_revisions
+USING chirps
+WHERE chirps.id = chirp_revisions.chirp_id
+AND chirps.deleted_at < sqlc.arg('deleted_before')::timestamp;
@@ -1,23 +1,233 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to, quote_of, status, publish_at, visibility, content_warning)
 VALUES (
-    gen_random_uuid(), NOW(), NOW(), $1, $2
+    gen_random_uuid(), COALESCE(sqlc.narg('publish_at')::timestamp, NOW()), NOW(),
+    sqlc.arg('body'), sqlc.arg('user_id'), sqlc.arg('in_reply_to'), sqlc.arg('quote_of'),
+    sqlc.arg('status'), sqlc.narg('publish_at'), sqlc.arg('visibility'), sqlc.arg('content_warning')
 )
 RETURNING *;
 
--- name: GetChirps :many
-SELECT * FROM chirps
-ORDER BY created_at ASC;
+-- name: CreateRechirp :exec
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, rechirp_of)
+VALUES (
+    gen_random_uuid(), NOW(), NOW(), '', $1, $2
+)
+ON CONFLICT (user_id, rechirp_of) WHERE rechirp_of IS NOT NULL DO NOTHING;
+
+-- name: DeleteRechirp :exec
+DELETE FROM chirps
+WHERE user_id = $1 AND rechirp_of = $2;
 
 -- name: GetChirp :one
 SELECT * FROM chirps
+WHERE id = $1
+AND deleted_at IS NULL;
+
+-- name: GetChirpWithDeleted :one
+SELECT * FROM chirps
 WHERE id = $1;
 
+-- name: GetChirpsByIDs :many
+SELECT * FROM chirps
+WHERE id = ANY(sqlc.arg('chirp_ids')::uuid[])
+AND chirp_visible_to(id, user_id, visibility, sqlc.narg('viewer_id')::uuid);
+
+-- name: IsChirpVisibleTo :one
+SELECT chirp_visible_to(id, user_id, visibility, sqlc.narg('viewer_id')::uuid) FROM chirps
+WHERE id = sqlc.arg('chirp_id');
+
 -- name: DeleteChirp :exec
 DELETE FROM chirps
 WHERE id = $1;
 
--- name: GetChirpsByUser :many
+-- name: SoftDeleteChirp :exec
+UPDATE chirps SET deleted_at = NOW()
+WHERE id = $1;
+
+-- name: SoftDeleteRechirpsOf :exec
+UPDATE chirps SET deleted_at = NOW()
+WHERE rechirp_of = $1
+AND deleted_at IS NULL;
+
+-- name: RestoreRechirpsOf :exec
+UPDATE chirps AS rechirps SET deleted_at = NULL
+FROM chirps AS originals
+WHERE originals.id = sqlc.arg('chirp_id')
+AND rechirps.rechirp_of = originals.id
+AND rechirps.deleted_at = originals.deleted_at;
+
+-- name: RestoreChirp :one
+UPDATE chirps SET deleted_at = NULL
+WHERE id = $1
+RETURNING *;
+
+-- name: SetChirpStatus :one
+UPDATE chirps
+SET status = sqlc.arg('status'), publish_at = sqlc.narg('publish_at'),
+    created_at = COALESCE(sqlc.narg('publish_at')::timestamp, NOW()), updated_at = NOW()
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: PublishScheduledChirps :execrows
+UPDATE chirps SET status = 'published', updated_at = NOW()
+WHERE status = 'scheduled'
+AND publish_at <= sqlc.arg('now')::timestamp;
+
+-- name: ListDraftChirps :many
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg('user_id')
+AND status = 'draft'
+AND deleted_at IS NULL
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: ListScheduledChirps :many
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg('user_id')
+AND status = 'scheduled'
+AND deleted_at IS NULL
+AND (
+    sqlc.narg('cursor_publish_at')::timestamp IS NULL
+    OR (publish_at, id) > (sqlc.narg('cursor_publish_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY publish_at ASC, id ASC
+LIMIT sqlc.arg('page_size');
+
+-- name: UpdateChirpBody :one
+UPDATE chirps SET body = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: ListDeletedChirps :many
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg('user_id')
+AND deleted_at IS NOT NULL
+AND rechirp_of IS NULL
+AND scrubbed_at IS NULL
+AND (
+    sqlc.narg('cursor_deleted_at')::timestamp IS NULL
+    OR (deleted_at, id) < (sqlc.narg('cursor_deleted_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY deleted_at DESC, id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: PurgeDeletedChirps :execrows
+DELETE FROM chirps
+WHERE deleted_at < sqlc.arg('deleted_before')::timestamp
+AND NOT EXISTS (
+    SELECT 1 FROM chirps AS refs
+    WHERE refs.in_reply_to = chirps.id OR refs.quote_of = chirps.id
+);
+
+-- name: ScrubChirp :exec
+UPDATE chirps SET body = '', content_warning = '', deleted_at = COALESCE(deleted_at, NOW()), scrubbed_at = NOW(), updated_at = NOW()
+WHERE id = $1;
+
+-- name: ScrubDeletedChirps :execrows
+UPDATE chirps SET body = '', content_warning = '', scrubbed_at = NOW(), updated_at = NOW()
+WHERE deleted_at < sqlc.arg('deleted_before')::timestamp
+AND scrubbed_at IS NULL;
+
+-- name: ListChirpsAsc :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+AND status = 'published'
+AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id')::uuid)
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+AND chirp_visible_to(id, user_id, visibility, sqlc.narg('viewer_id')::uuid)
+AND NOT chirp_muted_for(user_id, content_warning, body, sqlc.narg('viewer_id')::uuid)
+AND (
+    sqlc.narg('author_id')::uuid IS NOT NULL
+    OR NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.user_id = sqlc.narg('viewer_id')::uuid
+        AND mutes.muted_id = chirps.user_id
+    )
+)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('page_size');
+
+-- name: ListChirpsDesc :many
 SELECT * FROM chirps
-WHERE user_id = $1
-ORDER BY created_at ASC;
+WHERE deleted_at IS NULL
+AND status = 'published'
+AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id')::uuid)
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+AND chirp_visible_to(id, user_id, visibility, sqlc.narg('viewer_id')::uuid)
+AND NOT chirp_muted_for(user_id, content_warning, body, sqlc.narg('viewer_id')::uuid)
+AND (
+    sqlc.narg('author_id')::uuid IS NOT NULL
+    OR NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.user_id = sqlc.narg('viewer_id')::uuid
+        AND mutes.muted_id = chirps.user_id
+    )
+)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: ListChirpAncestors :many
+WITH RECURSIVE ancestors (id, in_reply_to, depth) AS (
+    SELECT c.id, c.in_reply_to, 0 FROM chirps c
+    WHERE c.id = sqlc.arg('chirp_id')
+    UNION ALL
+    SELECT c.id, c.in_reply_to, a.depth + 1 FROM chirps c
+    JOIN ancestors a ON c.id = a.in_reply_to
+)
+SELECT chirps.* FROM chirps
+JOIN ancestors ON ancestors.id = chirps.id
+WHERE ancestors.depth > 0
+AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id')::uuid)
+ORDER BY ancestors.depth DESC;
+
+-- name: ListChirpDescendants :many
+WITH RECURSIVE descendants (id, depth) AS (
+    SELECT c.id, 1 FROM chirps c
+    WHERE c.in_reply_to = sqlc.arg('chirp_id')
+    AND c.status = 'published'
+    AND chirp_visible_to(c.id, c.user_id, c.visibility, sqlc.narg('viewer_id')::uuid)
+    UNION ALL
+    SELECT c.id, d.depth + 1 FROM chirps c
+    JOIN descendants d ON c.in_reply_to = d.id
+    WHERE d.depth < sqlc.arg('max_depth')::int
+    AND c.status = 'published'
+    AND chirp_visible_to(c.id, c.user_id, c.visibility, sqlc.narg('viewer_id')::uuid)
+)
+SELECT chirps.* FROM chirps
+JOIN descendants ON descendants.id = chirps.id
+ORDER BY chirps.created_at ASC, chirps.id ASC
+LIMIT sqlc.arg('max_chirps');
+
+-- name: CountRepliesForChirps :many
+SELECT in_reply_to, COUNT(*) AS reply_count FROM chirps
+WHERE in_reply_to = ANY(sqlc.arg('chirp_ids')::uuid[])
+AND deleted_at IS NULL
+AND status = 'published'
+AND chirp_visible_to(id, user_id, visibility, sqlc.narg('viewer_id')::uuid)
+GROUP BY in_reply_to;
+
+-- name: CountRechirpsForChirps :many
+SELECT rechirp_of, COUNT(*) AS rechirp_count FROM chirps
+WHERE rechirp_of = ANY(sqlc.arg('chirp_ids')::uuid[])
+AND deleted_at IS NULL
+AND status = 'published'
+AND chirp_visible_to(id, user_id, visibility, sqlc.narg('viewer_id')::uuid)
+GROUP BY rechirp_of;
+
+-- name: CountQuotesForChirps :many
+SELECT quote_of, COUNT(*) AS quote_count FROM chirps
+WHERE quote_of = ANY(sqlc.arg('chirp_ids')::uuid[])
+AND deleted_at IS NULL
+AND status = 'published'
+AND chirp_visible_to(id, user_id, visibility, sqlc.narg('viewer_id')::uuid)
+GROUP BY quote_of;
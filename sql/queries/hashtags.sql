@@ -0,0 +1,70 @@
+-- name: UpsertHashtag :one
+INSERT INTO hashtags (id, created_at, tag)
+VALUES (
+    gen_random_uuid(), NOW(), $1
+)
+ON CONFLICT (tag) DO UPDATE SET tag = EXCLUDED.tag
+RETURNING *;
+
+-- name: AddChirpHashtag :exec
+INSERT INTO chirp_hashtags (chirp_id, hashtag_id, created_at)
+VALUES (
+    $1, $2, $3
+)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteChirpHashtags :exec
+DELETE FROM chirp_hashtags
+WHERE chirp_id = $1;
+
+-- name: ListChirpsByHashtag :many
+SELECT chirps.* FROM chirps
+JOIN chirp_hashtags ON chirp_hashtags.chirp_id = chirps.id
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+WHERE hashtags.tag = sqlc.arg('tag')
+AND chirps.deleted_at IS NULL
+AND chirps.status = 'published'
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (chirps.created_at, chirps.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id')::uuid)
+AND NOT chirp_muted_for(chirps.user_id, chirps.content_warning, chirps.body, sqlc.narg('viewer_id')::uuid)
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: ClearTrendingHashtags :exec
+DELETE FROM trending_hashtags;
+
+-- name: ComputeTrendingHashtags :exec
+INSERT INTO trending_hashtags (hashtag_id, recent_count, previous_count, score, computed_at)
+SELECT hashtag_id, recent_count, previous_count,
+    (recent_count - previous_count)::float8 / (previous_count + 1), NOW()
+FROM (
+    SELECT chirp_hashtags.hashtag_id,
+        COUNT(*) FILTER (WHERE chirp_hashtags.created_at >= sqlc.arg('recent_since')::timestamp) AS recent_count,
+        COUNT(*) FILTER (WHERE chirp_hashtags.created_at < sqlc.arg('recent_since')::timestamp) AS previous_count
+    FROM chirp_hashtags
+    JOIN chirps ON chirps.id = chirp_hashtags.chirp_id
+    WHERE chirp_hashtags.created_at >= sqlc.arg('previous_since')::timestamp
+    AND chirps.deleted_at IS NULL
+    AND chirps.status = 'published'
+    AND chirps.visibility = 'public'
+    AND author_visible_to(chirps.user_id, NULL)
+    GROUP BY chirp_hashtags.hashtag_id
+) AS counts
+WHERE recent_count >= sqlc.arg('min_count')::bigint
+AND recent_count > previous_count;
+
+-- name: ListTrendingHashtags :many
+SELECT hashtags.tag, trending_hashtags.recent_count, trending_hashtags.score, trending_hashtags.computed_at
+FROM trending_hashtags
+JOIN hashtags ON hashtags.id = trending_hashtags.hashtag_id
+ORDER BY trending_hashtags.score DESC, trending_hashtags.recent_count DESC
+LIMIT $1;
+
+-- name: DeleteHashtagsOfDeletedChirps :exec
+DELETE FROM chirp_hashtags
+USING chirps
+WHERE chirps.id = chirp_hashtags.chirp_id
+AND chirps.deleted_at < sqlc.arg('deleted_before')::timestamp;
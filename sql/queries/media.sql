@@ -0,0 +1,73 @@
+-- name: CreateMedia :one
+INSERT INTO media (id, created_at, user_id, content_type, size_bytes, storage_key)
+VALUES (
+    $1, NOW(), $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: GetMediaUsage :one
+SELECT COALESCE(SUM(size_bytes), 0)::bigint AS total_bytes FROM media
+WHERE user_id = $1;
+
+-- name: AttachMedia :execrows
+UPDATE media
+SET chirp_id = sqlc.arg('chirp_id'), position = array_position(sqlc.arg('media_ids')::uuid[], id)
+WHERE id = ANY(sqlc.arg('media_ids')::uuid[])
+AND user_id = sqlc.arg('user_id')
+AND chirp_id IS NULL;
+
+-- name: ListMediaForChirps :many
+SELECT * FROM media
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_id, position;
+
+-- name: DetachMediaOfDeletedChirps :exec
+UPDATE media SET chirp_id = NULL
+FROM chirps
+WHERE chirps.id = media.chirp_id
+AND chirps.deleted_at < sqlc.arg('deleted_before')::timestamp;
+
+-- name: GetMedia :one
+SELECT * FROM media
+WHERE id = $1;
+
+-- name: ListOrphanedMedia :many
+SELECT * FROM media
+WHERE chirp_id IS NULL
+AND created_at < sqlc.arg('created_before')::timestamp
+AND NOT EXISTS (
+    SELECT 1 FROM users
+    WHERE users.avatar_media_id = media.id
+);
+
+-- name: DeleteMedia :exec
+DELETE FROM media
+WHERE id = $1;
+
+-- name: ListPendingMedia :many
+SELECT * FROM media
+WHERE status = 'pending'
+ORDER BY created_at
+LIMIT $1;
+
+-- name: MarkMediaReady :exec
+UPDATE media SET status = 'ready', width = $2, height = $3, blurhash = $4
+WHERE id = $1;
+
+-- name: MarkMediaFailed :exec
+UPDATE media SET status = 'failed'
+WHERE id = $1;
+
+-- name: AddMediaVariant :exec
+INSERT INTO media_variants (media_id, name, width, height, content_type, storage_key)
+VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (media_id, name) DO UPDATE
+SET width = EXCLUDED.width, height = EXCLUDED.height,
+    content_type = EXCLUDED.content_type, storage_key = EXCLUDED.storage_key;
+
+-- name: ListVariantsForMedia :many
+SELECT * FROM media_variants
+WHERE media_id = ANY(sqlc.arg('media_ids')::uuid[])
+ORDER BY media_id, width;
@@ -0,0 +1,34 @@
+-- name: AddMention :exec
+INSERT INTO mentions (chirp_id, user_id, handle, created_at)
+VALUES (
+    $1, $2, $3, NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteChirpMentions :exec
+DELETE FROM mentions
+WHERE chirp_id = $1;
+
+-- name: ListMentionsForChirps :many
+SELECT chirp_id, user_id, handle FROM mentions
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: ListMentioningChirps :many
+SELECT chirps.* FROM chirps
+JOIN mentions ON mentions.chirp_id = chirps.id
+WHERE mentions.user_id = sqlc.arg('user_id')
+AND chirps.deleted_at IS NULL
+AND chirps.status = 'published'
+AND author_visible_to(chirps.user_id, sqlc.arg('user_id'))
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (chirps.created_at, chirps.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: DeleteMentionsOfDeletedChirps :exec
+DELETE FROM mentions
+USING chirps
+WHERE chirps.id = mentions.chirp_id
+AND chirps.deleted_at < sqlc.arg('deleted_before')::timestamp;
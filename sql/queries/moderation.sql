@@ -0,0 +1,34 @@
+-- name: CreateModerationRule :one
+INSERT INTO moderation_rules (id, created_at, updated_at, kind, pattern, action)
+VALUES (
+    gen_random_uuid(), NOW(), NOW(), $1, $2, $3
+)
+RETURNING *;
+
+-- name: UpdateModerationRule :one
+UPDATE moderation_rules SET kind = $2, pattern = $3, action = $4, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteModerationRule :execrows
+DELETE FROM moderation_rules
+WHERE id = $1;
+
+-- name: ListModerationRules :many
+SELECT * FROM moderation_rules
+ORDER BY kind, pattern;
+
+-- name: CreateModerationFlag :exec
+INSERT INTO moderation_flags (id, created_at, chirp_id, rule_id, matched)
+VALUES (
+    gen_random_uuid(), NOW(), $1, $2, $3
+);
+
+-- name: ListModerationFlags :many
+SELECT * FROM moderation_flags
+WHERE (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_size');
@@ -0,0 +1,31 @@
+-- name: CreateMutedWord :one
+INSERT INTO muted_words (id, created_at, user_id, phrase, whole_word, pattern, expires_at)
+VALUES (
+    gen_random_uuid(), NOW(), $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: DeleteMutedWord :execrows
+DELETE FROM muted_words
+WHERE id = $1 AND user_id = $2;
+
+-- name: ListMutedWords :many
+SELECT * FROM muted_words
+WHERE user_id = $1
+AND (expires_at IS NULL OR expires_at > NOW())
+ORDER BY created_at DESC, id DESC;
+
+-- name: CountMutedWords :one
+SELECT COUNT(*) FROM muted_words
+WHERE user_id = $1
+AND (expires_at IS NULL OR expires_at > NOW());
+
+-- name: DeleteExpiredMutedWords :execrows
+DELETE FROM muted_words
+WHERE expires_at <= NOW();
+
+-- name: DeleteExpiredMutedPhrase :exec
+DELETE FROM muted_words
+WHERE user_id = $1
+AND LOWER(phrase) = LOWER($2)
+AND expires_at <= NOW();
@@ -0,0 +1,30 @@
+-- name: PinChirp :exec
+INSERT INTO pinned_chirps (user_id, chirp_id, pinned_at)
+VALUES (
+    $1, $2, NOW()
+)
+ON CONFLICT (user_id, chirp_id) DO UPDATE SET pinned_at = NOW();
+
+-- name: UnpinChirp :exec
+DELETE FROM pinned_chirps
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: TrimPinnedChirps :exec
+DELETE FROM pinned_chirps
+WHERE pinned_chirps.user_id = sqlc.arg('user_id')
+AND chirp_id NOT IN (
+    SELECT newest.chirp_id FROM pinned_chirps AS newest
+    WHERE newest.user_id = sqlc.arg('user_id')
+    ORDER BY newest.pinned_at DESC
+    LIMIT sqlc.arg('max_pins')::int
+);
+
+-- name: ListPinnedChirps :many
+SELECT chirps.* FROM chirps
+JOIN pinned_chirps ON pinned_chirps.chirp_id = chirps.id
+WHERE pinned_chirps.user_id = sqlc.arg('user_id')
+AND chirps.deleted_at IS NULL
+AND chirps.status = 'published'
+AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id')::uuid)
+ORDER BY pinned_chirps.pinned_at DESC
+LIMIT sqlc.arg('max_pins')::int;
@@ -0,0 +1,43 @@
+-- name: CreatePoll :exec
+INSERT INTO polls (chirp_id, created_at, expires_at, results)
+VALUES (
+    $1, NOW(), $2, $3
+);
+
+-- name: AddPollOption :exec
+INSERT INTO poll_options (chirp_id, position, text)
+VALUES (
+    $1, $2, $3
+);
+
+-- name: GetPoll :one
+SELECT * FROM polls
+WHERE chirp_id = $1;
+
+-- name: CreatePollVote :exec
+INSERT INTO poll_votes (chirp_id, user_id, position, created_at)
+VALUES (
+    $1, $2, $3, NOW()
+);
+
+-- name: ListPollsForChirps :many
+SELECT * FROM polls
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: ListPollOptionsForChirps :many
+SELECT poll_options.chirp_id, poll_options.position, poll_options.text, COUNT(poll_votes.user_id) AS vote_count
+FROM poll_options
+LEFT JOIN poll_votes ON poll_votes.chirp_id = poll_options.chirp_id
+AND poll_votes.position = poll_options.position
+WHERE poll_options.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+GROUP BY poll_options.chirp_id, poll_options.position, poll_options.text
+ORDER BY poll_options.chirp_id, poll_options.position;
+
+-- name: ListPollVotesByUser :many
+SELECT chirp_id, position FROM poll_votes
+WHERE user_id = sqlc.arg('user_id')
+AND chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: ReschedulePoll :exec
+UPDATE polls SET expires_at = expires_at + (sqlc.arg('created_at')::timestamp - sqlc.arg('previous_created_at')::timestamp)
+WHERE chirp_id = sqlc.arg('chirp_id');
@@ -15,6 +15,12 @@ updated_at = NOW()
 WHERE token = $1
 RETURNING *;
 
+-- name: ListActiveRefreshTokens :many
+SELECT token FROM refresh_tokens
+WHERE user_id = $1
+AND revoked_at IS NULL
+AND expires_at > NOW();
+
 -- name: GetUserFromRefreshToken :one
 SELECT users.* FROM users
 JOIN refresh_tokens ON users.id = refresh_tokens.user_id
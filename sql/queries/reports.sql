@@ -0,0 +1,48 @@
+-- name: CreateReport :one
+INSERT INTO reports (id, created_at, updated_at, reporter_id, user_id, chirp_id, reason, details)
+VALUES (
+    gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: GetReport :one
+SELECT * FROM reports
+WHERE id = $1;
+
+-- name: ListReports :many
+SELECT * FROM reports
+WHERE (
+    (sqlc.narg('status')::text IS NULL AND status <> 'resolved')
+    OR status = sqlc.narg('status')::text
+)
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('page_size');
+
+-- name: ClaimReport :one
+UPDATE reports SET status = 'claimed', claimed_by = sqlc.arg('moderator_id'), updated_at = NOW()
+WHERE id = sqlc.arg('id')
+AND status <> 'resolved'
+AND (claimed_by IS NULL OR claimed_by = sqlc.arg('moderator_id'))
+RETURNING *;
+
+-- name: ResolveReport :one
+UPDATE reports SET status = 'resolved', claimed_by = sqlc.arg('moderator_id'), resolution = sqlc.arg('resolution'), updated_at = NOW()
+WHERE id = sqlc.arg('id')
+AND status <> 'resolved'
+AND (claimed_by IS NULL OR claimed_by = sqlc.arg('moderator_id'))
+RETURNING *;
+
+-- name: CreateModerationDecision :exec
+INSERT INTO moderation_decisions (id, created_at, moderator_id, report_id, action, user_id, chirp_id, note)
+VALUES (
+    gen_random_uuid(), NOW(), $1, $2, $3, $4, $5, $6
+);
+
+-- name: ListReportDecisions :many
+SELECT * FROM moderation_decisions
+WHERE report_id = $1
+ORDER BY created_at ASC, id ASC;
@@ -0,0 +1,35 @@
+-- name: SearchChirpsByRecency :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+AND status = 'published'
+AND (sqlc.arg('query')::text = '' OR search_vector @@ websearch_to_tsquery('english', sqlc.arg('query')::text))
+AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id')::uuid)
+AND (sqlc.narg('since')::timestamp IS NULL OR created_at >= sqlc.narg('since')::timestamp)
+AND (sqlc.narg('until')::timestamp IS NULL OR created_at < sqlc.narg('until')::timestamp)
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+AND chirp_visible_to(id, user_id, visibility, sqlc.narg('viewer_id')::uuid)
+AND NOT chirp_muted_for(user_id, content_warning, body, sqlc.narg('viewer_id')::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: SearchChirpsByRelevance :many
+SELECT sqlc.embed(chirps), ts_rank(chirps.search_vector, websearch_to_tsquery('english', sqlc.arg('query')::text)) AS rank
+FROM chirps
+WHERE chirps.deleted_at IS NULL
+AND chirps.status = 'published'
+AND chirps.search_vector @@ websearch_to_tsquery('english', sqlc.arg('query')::text)
+AND (sqlc.narg('author_id')::uuid IS NULL OR chirps.user_id = sqlc.narg('author_id')::uuid)
+AND (sqlc.narg('since')::timestamp IS NULL OR chirps.created_at >= sqlc.narg('since')::timestamp)
+AND (sqlc.narg('until')::timestamp IS NULL OR chirps.created_at < sqlc.narg('until')::timestamp)
+AND (
+    sqlc.narg('cursor_rank')::real IS NULL
+    OR (ts_rank(chirps.search_vector, websearch_to_tsquery('english', sqlc.arg('query')::text)), chirps.id)
+        < (sqlc.narg('cursor_rank')::real, sqlc.narg('cursor_id')::uuid)
+)
+AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.narg('viewer_id')::uuid)
+AND NOT chirp_muted_for(chirps.user_id, chirps.content_warning, chirps.body, sqlc.narg('viewer_id')::uuid)
+ORDER BY rank DESC, chirps.id DESC
+LIMIT sqlc.arg('page_size');
@@ -1,7 +1,7 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle)
 VALUES (
-    gen_random_uuid(), NOW(), NOW(), $1, $2
+    gen_random_uuid(), NOW(), NOW(), $1, $2, $3
 )
 RETURNING *;
 
@@ -21,3 +21,55 @@ RETURNING *;
 UPDATE users SET is_chirpy_red = true
 WHERE id = $1
 RETURNING *;
+
+-- name: GetUser :one
+SELECT * FROM users
+WHERE id = $1;
+
+-- name: GetUserByHandle :one
+SELECT * FROM users
+WHERE LOWER(handle) = LOWER(sqlc.arg('handle'));
+
+-- name: GetUsersByHandles :many
+SELECT * FROM users
+WHERE LOWER(handle) = ANY(sqlc.arg('handles')::text[]);
+
+-- name: SetUserHandle :one
+UPDATE users SET handle = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SuspendUser :exec
+UPDATE users SET suspended_at = NOW(), suspended_until = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: LiftSuspension :exec
+UPDATE users SET suspended_at = NULL, suspended_until = NULL, updated_at = NOW()
+WHERE id = $1;
+
+-- name: LiftExpiredSuspensions :execrows
+UPDATE users SET suspended_at = NULL, suspended_until = NULL, updated_at = NOW()
+WHERE suspended_until <= NOW();
+
+-- name: ShadowbanUser :exec
+UPDATE users SET shadowbanned_at = COALESCE(shadowbanned_at, NOW()), updated_at = NOW()
+WHERE id = $1;
+
+-- name: LiftShadowban :exec
+UPDATE users SET shadowbanned_at = NULL, updated_at = NOW()
+WHERE id = $1;
+
+-- name: UpdateUserProfile :one
+UPDATE users SET
+    handle = COALESCE(sqlc.narg('handle'), handle),
+    display_name = COALESCE(sqlc.narg('display_name'), display_name),
+    bio = COALESCE(sqlc.narg('bio'), bio),
+    website = COALESCE(sqlc.narg('website'), website),
+    location = COALESCE(sqlc.narg('location'), location),
+    avatar_media_id = CASE WHEN sqlc.arg('set_avatar')::boolean THEN sqlc.narg('avatar_media_id')::uuid ELSE avatar_media_id END,
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: IsUserVisibleTo :one
+SELECT author_visible_to(sqlc.arg('user_id')::uuid, sqlc.narg('viewer_id')::uuid);
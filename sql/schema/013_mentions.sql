@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN handle TEXT;
+CREATE UNIQUE INDEX users_handle_idx ON users (LOWER(handle));
+
+CREATE TABLE mentions (
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    handle TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (chirp_id, user_id)
+);
+CREATE INDEX mentions_user_id_created_at_idx ON mentions (user_id, created_at);
+
+-- +goose Down
+DROP TABLE mentions;
+DROP INDEX users_handle_idx;
+ALTER TABLE users DROP COLUMN handle;
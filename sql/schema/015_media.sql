@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE media (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    storage_key TEXT NOT NULL,
+    chirp_id UUID REFERENCES chirps(id) ON DELETE SET NULL,
+    position INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX media_user_id_idx ON media (user_id);
+CREATE INDEX media_chirp_id_position_idx ON media (chirp_id, position);
+
+-- +goose Down
+DROP TABLE media;
@@ -0,0 +1,26 @@
+-- +goose Up
+ALTER TABLE media
+ADD COLUMN status TEXT NOT NULL DEFAULT 'pending',
+ADD COLUMN width INTEGER,
+ADD COLUMN height INTEGER,
+ADD COLUMN blurhash TEXT;
+CREATE INDEX media_pending_idx ON media (created_at) WHERE status = 'pending';
+
+CREATE TABLE media_variants (
+    media_id UUID NOT NULL REFERENCES media(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    content_type TEXT NOT NULL,
+    storage_key TEXT NOT NULL,
+    PRIMARY KEY (media_id, name)
+);
+
+-- +goose Down
+DROP TABLE media_variants;
+DROP INDEX media_pending_idx;
+ALTER TABLE media
+DROP COLUMN status,
+DROP COLUMN width,
+DROP COLUMN height,
+DROP COLUMN blurhash;
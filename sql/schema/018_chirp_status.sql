@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN status TEXT NOT NULL DEFAULT 'published',
+ADD COLUMN publish_at TIMESTAMP;
+CREATE INDEX chirps_scheduled_idx ON chirps (publish_at) WHERE status = 'scheduled';
+CREATE INDEX chirps_unpublished_user_id_idx ON chirps (user_id, created_at) WHERE status <> 'published';
+
+-- +goose Down
+DROP INDEX chirps_unpublished_user_id_idx;
+DROP INDEX chirps_scheduled_idx;
+ALTER TABLE chirps
+DROP COLUMN status,
+DROP COLUMN publish_at;
@@ -0,0 +1,27 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public';
+
+-- Authors and mentioned users see everything, followers also see followers-only chirps.
+-- +goose StatementBegin
+CREATE FUNCTION chirp_visible_to(chirp_id UUID, author_id UUID, visibility TEXT, viewer_id UUID)
+RETURNS BOOLEAN AS $$
+    SELECT visibility = 'public'
+    OR author_id = viewer_id
+    OR EXISTS (
+        SELECT 1 FROM mentions
+        WHERE mentions.chirp_id = chirp_visible_to.chirp_id
+        AND mentions.user_id = viewer_id
+    )
+    OR (visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = viewer_id
+        AND follows.followee_id = author_id
+    ));
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION chirp_visible_to;
+ALTER TABLE chirps
+DROP COLUMN visibility;
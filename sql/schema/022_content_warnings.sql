@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN content_warning TEXT NOT NULL DEFAULT '';
+-- Content warnings are searchable too, so the search vector has to be rebuilt to include them.
+DROP INDEX chirps_search_vector_idx;
+ALTER TABLE chirps
+DROP COLUMN search_vector;
+ALTER TABLE chirps
+ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (to_tsvector('english', content_warning || ' ' || body)) STORED;
+CREATE INDEX chirps_search_vector_idx ON chirps USING GIN (search_vector);
+
+-- +goose Down
+DROP INDEX chirps_search_vector_idx;
+ALTER TABLE chirps
+DROP COLUMN search_vector;
+ALTER TABLE chirps
+ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (to_tsvector('english', body)) STORED;
+CREATE INDEX chirps_search_vector_idx ON chirps USING GIN (search_vector);
+ALTER TABLE chirps
+DROP COLUMN content_warning;
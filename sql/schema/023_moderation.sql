@@ -0,0 +1,34 @@
+-- +goose Up
+CREATE TABLE moderation_rules (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    kind TEXT NOT NULL,
+    pattern TEXT NOT NULL,
+    action TEXT NOT NULL,
+    UNIQUE (kind, pattern)
+);
+-- The words that used to be hard-coded.
+INSERT INTO moderation_rules (id, created_at, updated_at, kind, pattern, action)
+VALUES
+    (gen_random_uuid(), NOW(), NOW(), 'word', 'kerfuffle', 'mask'),
+    (gen_random_uuid(), NOW(), NOW(), 'word', 'sharbert', 'mask'),
+    (gen_random_uuid(), NOW(), NOW(), 'word', 'fornax', 'mask');
+
+CREATE TABLE moderation_flags (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    rule_id UUID REFERENCES moderation_rules(id) ON DELETE SET NULL,
+    matched TEXT NOT NULL
+);
+CREATE INDEX moderation_flags_created_at_idx ON moderation_flags (created_at);
+
+ALTER TABLE users
+ADD COLUMN is_moderator BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN is_moderator;
+DROP TABLE moderation_flags;
+DROP TABLE moderation_rules;
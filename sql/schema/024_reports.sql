@@ -0,0 +1,38 @@
+-- +goose Up
+CREATE TABLE reports (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    reporter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- The reported account, or the author of the reported chirp.
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID REFERENCES chirps(id) ON DELETE SET NULL,
+    reason TEXT NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'open',
+    claimed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolution TEXT
+);
+CREATE INDEX reports_unresolved_created_at_idx ON reports (created_at) WHERE status <> 'resolved';
+
+-- The audit trail deliberately has no foreign keys on its targets, so it outlives them.
+CREATE TABLE moderation_decisions (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    moderator_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    report_id UUID REFERENCES reports(id) ON DELETE SET NULL,
+    action TEXT NOT NULL,
+    user_id UUID,
+    chirp_id UUID,
+    note TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX moderation_decisions_report_id_idx ON moderation_decisions (report_id);
+
+ALTER TABLE users
+ADD COLUMN suspended_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN suspended_at;
+DROP TABLE moderation_decisions;
+DROP TABLE reports;
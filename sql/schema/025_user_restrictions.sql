@@ -0,0 +1,63 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN suspended_until TIMESTAMP,
+ADD COLUMN shadowbanned_at TIMESTAMP;
+
+-- Suspended authors are hidden from everyone, shadowbanned authors from everyone but
+-- themselves. A suspension without an end lasts until it is lifted.
+-- +goose StatementBegin
+CREATE FUNCTION author_visible_to(author_id UUID, viewer_id UUID)
+RETURNS BOOLEAN AS $$
+    SELECT NOT EXISTS (
+        SELECT 1 FROM users
+        WHERE users.id = author_id
+        AND (
+            (users.suspended_at IS NOT NULL AND (users.suspended_until IS NULL OR users.suspended_until > NOW()))
+            OR (users.shadowbanned_at IS NOT NULL AND author_id IS DISTINCT FROM viewer_id)
+        )
+    );
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION chirp_visible_to(chirp_id UUID, author_id UUID, visibility TEXT, viewer_id UUID)
+RETURNS BOOLEAN AS $$
+    SELECT author_visible_to(author_id, viewer_id) AND (
+        visibility = 'public'
+        OR author_id = viewer_id
+        OR EXISTS (
+            SELECT 1 FROM mentions
+            WHERE mentions.chirp_id = chirp_visible_to.chirp_id
+            AND mentions.user_id = viewer_id
+        )
+        OR (visibility = 'followers' AND EXISTS (
+            SELECT 1 FROM follows
+            WHERE follows.follower_id = viewer_id
+            AND follows.followee_id = author_id
+        ))
+    );
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION chirp_visible_to(chirp_id UUID, author_id UUID, visibility TEXT, viewer_id UUID)
+RETURNS BOOLEAN AS $$
+    SELECT visibility = 'public'
+    OR author_id = viewer_id
+    OR EXISTS (
+        SELECT 1 FROM mentions
+        WHERE mentions.chirp_id = chirp_visible_to.chirp_id
+        AND mentions.user_id = viewer_id
+    )
+    OR (visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = viewer_id
+        AND follows.followee_id = author_id
+    ));
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+DROP FUNCTION author_visible_to;
+ALTER TABLE users
+DROP COLUMN suspended_until,
+DROP COLUMN shadowbanned_at;
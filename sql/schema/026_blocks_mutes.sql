@@ -0,0 +1,52 @@
+-- +goose Up
+CREATE TABLE blocks (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    blocked_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, blocked_id)
+);
+CREATE INDEX blocks_blocked_id_idx ON blocks (blocked_id);
+
+CREATE TABLE mutes (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    muted_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, muted_id)
+);
+
+-- Blocked users can't see anything of the person who blocked them.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION author_visible_to(author_id UUID, viewer_id UUID)
+RETURNS BOOLEAN AS $$
+    SELECT NOT EXISTS (
+        SELECT 1 FROM users
+        WHERE users.id = author_id
+        AND (
+            (users.suspended_at IS NOT NULL AND (users.suspended_until IS NULL OR users.suspended_until > NOW()))
+            OR (users.shadowbanned_at IS NOT NULL AND author_id IS DISTINCT FROM viewer_id)
+        )
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE blocks.user_id = author_id
+        AND blocks.blocked_id = viewer_id
+    );
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION author_visible_to(author_id UUID, viewer_id UUID)
+RETURNS BOOLEAN AS $$
+    SELECT NOT EXISTS (
+        SELECT 1 FROM users
+        WHERE users.id = author_id
+        AND (
+            (users.suspended_at IS NOT NULL AND (users.suspended_until IS NULL OR users.suspended_until > NOW()))
+            OR (users.shadowbanned_at IS NOT NULL AND author_id IS DISTINCT FROM viewer_id)
+        )
+    );
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+DROP TABLE mutes;
+DROP TABLE blocks;
@@ -0,0 +1,29 @@
+-- +goose Up
+CREATE TABLE muted_words (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    phrase TEXT NOT NULL,
+    whole_word BOOLEAN NOT NULL,
+    -- A case-insensitive regular expression built from phrase when the filter is saved.
+    pattern TEXT NOT NULL,
+    expires_at TIMESTAMP
+);
+CREATE UNIQUE INDEX muted_words_user_id_phrase_idx ON muted_words (user_id, LOWER(phrase));
+
+-- Viewers never have their own chirps hidden from them.
+-- +goose StatementBegin
+CREATE FUNCTION chirp_muted_for(author_id UUID, content_warning TEXT, body TEXT, viewer_id UUID)
+RETURNS BOOLEAN AS $$
+    SELECT author_id IS DISTINCT FROM viewer_id AND EXISTS (
+        SELECT 1 FROM muted_words
+        WHERE muted_words.user_id = viewer_id
+        AND (muted_words.expires_at IS NULL OR muted_words.expires_at > NOW())
+        AND (content_warning || ' ' || body) ~* muted_words.pattern
+    );
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION chirp_muted_for;
+DROP TABLE muted_words;
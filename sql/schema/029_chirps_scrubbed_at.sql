@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN scrubbed_at TIMESTAMP;
+
+-- Chirps scrubbed so far are the deleted ones whose empty body was written after the deletion.
+UPDATE chirps SET scrubbed_at = updated_at
+WHERE deleted_at IS NOT NULL
+AND body = ''
+AND updated_at > deleted_at;
+
+-- +goose Down
+ALTER TABLE chirps DROP COLUMN scrubbed_at;
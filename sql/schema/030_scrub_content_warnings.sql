@@ -0,0 +1,7 @@
+-- +goose Up
+-- Tombstones scrubbed before content warnings were cleared with the body.
+UPDATE chirps SET content_warning = ''
+WHERE scrubbed_at IS NOT NULL;
+
+-- +goose Down
+-- Scrubbed content warnings can't be brought back.
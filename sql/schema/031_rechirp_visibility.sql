@@ -0,0 +1,49 @@
+-- +goose Up
+-- A rechirp has no content of its own, so it is only visible while the chirp it shares is.
+-- Rechirps always point at the original, never at another rechirp.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION chirp_visible_to(chirp_id UUID, author_id UUID, visibility TEXT, viewer_id UUID)
+RETURNS BOOLEAN AS $$
+    SELECT author_visible_to(author_id, viewer_id) AND (
+        visibility = 'public'
+        OR author_id = viewer_id
+        OR EXISTS (
+            SELECT 1 FROM mentions
+            WHERE mentions.chirp_id = chirp_visible_to.chirp_id
+            AND mentions.user_id = viewer_id
+        )
+        OR (visibility = 'followers' AND EXISTS (
+            SELECT 1 FROM follows
+            WHERE follows.follower_id = viewer_id
+            AND follows.followee_id = author_id
+        ))
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM chirps AS rechirps
+        JOIN chirps AS originals ON originals.id = rechirps.rechirp_of
+        WHERE rechirps.id = chirp_visible_to.chirp_id
+        AND NOT chirp_visible_to(originals.id, originals.user_id, originals.visibility, viewer_id)
+    );
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION chirp_visible_to(chirp_id UUID, author_id UUID, visibility TEXT, viewer_id UUID)
+RETURNS BOOLEAN AS $$
+    SELECT author_visible_to(author_id, viewer_id) AND (
+        visibility = 'public'
+        OR author_id = viewer_id
+        OR EXISTS (
+            SELECT 1 FROM mentions
+            WHERE mentions.chirp_id = chirp_visible_to.chirp_id
+            AND mentions.user_id = viewer_id
+        )
+        OR (visibility = 'followers' AND EXISTS (
+            SELECT 1 FROM follows
+            WHERE follows.follower_id = viewer_id
+            AND follows.followee_id = author_id
+        ))
+    );
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/docherak/bd-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	maxThreadDepth   = 32
+	maxThreadReplies = 500
+)
+
+type ThreadNode struct {
+	Chirp
+	Replies []*ThreadNode `json:"replies"`
+}
+
+type Thread struct {
+	Ancestors []Chirp     `json:"ancestors"`
+	Chirp     *ThreadNode `json:"chirp"`
+}
+
+func (cfg *apiConfig) handlerChirpsGetThread(w http.ResponseWriter, r *http.Request) {
+	chirpIDString := r.PathValue("chirpID")
+	chirpID, err := uuid.Parse(chirpIDString)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't parse UUID", err)
+		return
+	}
+
+	viewerID := cfg.getViewerID(r)
+	dbChirp, err := cfg.db.GetChirpWithDeleted(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", err)
+		return
+	}
+	visible, err := cfg.canViewChirp(r.Context(), viewerID, dbChirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting thread", err)
+		return
+	}
+	if !visible {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", nil)
+		return
+	}
+
+	// Replies and ancestors the viewer can't see are left out. Replies below a hidden one go with it.
+	dbAncestors, err := cfg.db.ListChirpAncestors(r.Context(), database.ListChirpAncestorsParams{
+		ChirpID:  chirpID,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting thread", err)
+		return
+	}
+
+	dbDescendants, err := cfg.db.ListChirpDescendants(r.Context(), database.ListChirpDescendantsParams{
+		ChirpID:   chirpID,
+		ViewerID:  viewerID,
+		MaxDepth:  maxThreadDepth,
+		MaxChirps: maxThreadReplies,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting thread", err)
+		return
+	}
+
+	dbThread := append(append(dbAncestors, dbChirp), dbDescendants...)
+	apiThread, err := cfg.databaseChirpsToAPIChirps(r.Context(), viewerID, dbThread)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting thread", err)
+		return
+	}
+
+	ancestors := apiThread[:len(dbAncestors)]
+	root := &ThreadNode{Chirp: apiThread[len(dbAncestors)], Replies: []*ThreadNode{}}
+	nodes := map[uuid.UUID]*ThreadNode{root.ID: root}
+	// Descendants come ordered by creation time, so every parent is seen before its replies.
+	for _, apiChirp := range apiThread[len(dbAncestors)+1:] {
+		node := &ThreadNode{Chirp: apiChirp, Replies: []*ThreadNode{}}
+		nodes[node.ID] = node
+		if parent, ok := nodes[*apiChirp.InReplyTo]; ok {
+			parent.Replies = append(parent.Replies, node)
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, Thread{
+		Ancestors: ancestors,
+		Chirp:     root,
+	})
+}
+
+func (cfg *apiConfig) addReplyCounts(ctx context.Context, viewerID uuid.NullUUID, apiChirps []Chirp) error {
+	replyCounts, err := cfg.db.CountRepliesForChirps(ctx, database.CountRepliesForChirpsParams{
+		ChirpIds: chirpIDs(apiChirps),
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		return err
+	}
+	replyCountByID := map[uuid.UUID]int64{}
+	for _, row := range replyCounts {
+		replyCountByID[row.InReplyTo.UUID] = row.ReplyCount
+	}
+
+	for i := range apiChirps {
+		apiChirps[i].ReplyCount = replyCountByID[apiChirps[i].ID]
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+
+	"github.com/docherak/bd-chirpy/internal/database"
+	"github.com/docherak/bd-chirpy/internal/imaging"
+)
+
+const (
+	mediaProcessingBatch = 10
+	blurhashSize         = 32
+)
+
+type thumbnailSize struct {
+	Name    string
+	MaxSize int
+}
+
+// thumbnailSizes are generated smallest first, and only when smaller than the original.
+var thumbnailSizes = []thumbnailSize{
+	{Name: "small", MaxSize: 160},
+	{Name: "medium", MaxSize: 480},
+	{Name: "large", MaxSize: 1280},
+}
+
+// processPendingMedia generates thumbnails and a blurhash for uploaded images. Images that
+// can't be read, decoded or stored are marked as failed rather than retried forever, so one
+// bad file doesn't hold up the ones queued behind it.
+func (cfg *apiConfig) processPendingMedia(ctx context.Context) error {
+	pending, err := cfg.db.ListPendingMedia(ctx, mediaProcessingBatch)
+	if err != nil {
+		return err
+	}
+	for _, media := range pending {
+		err := cfg.processMedia(ctx, media)
+		if err == nil {
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Printf("Couldn't process media %s: %s", media.ID, err)
+		err = cfg.db.MarkMediaFailed(ctx, media.ID)
+		if err != nil {
+			return fmt.Errorf("marking media %s as failed: %w", media.ID, err)
+		}
+	}
+	return nil
+}
+
+func (cfg *apiConfig) processMedia(ctx context.Context, media database.Medium) error {
+	f, err := cfg.storage.Open(ctx, media.StorageKey)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	img, err := imaging.Decode(data)
+	if err != nil {
+		return err
+	}
+	// Resizing first and rotating the much smaller result is a lot cheaper. Fitting into a
+	// square box is not affected by the rotation.
+	orientation := imaging.Orientation(data)
+	width, height := imaging.OrientedSize(img.Bounds().Dx(), img.Bounds().Dy(), orientation)
+
+	variants := []database.AddMediaVariantParams{}
+	for _, size := range thumbnailSizes {
+		if width <= size.MaxSize && height <= size.MaxSize {
+			break
+		}
+		thumbnail := imaging.Orient(imaging.Fit(img, size.MaxSize), orientation)
+		variant, err := cfg.storeThumbnail(ctx, media, size.Name, thumbnail)
+		if err != nil {
+			return err
+		}
+		variants = append(variants, variant)
+	}
+	blurhash := imaging.Blurhash(imaging.Orient(imaging.Fit(img, blurhashSize), orientation), 4, 3)
+
+	return cfg.withTx(ctx, func(q *database.Queries) error {
+		for _, variant := range variants {
+			err := q.AddMediaVariant(ctx, variant)
+			if err != nil {
+				return err
+			}
+		}
+		return q.MarkMediaReady(ctx, database.MarkMediaReadyParams{
+			ID:       media.ID,
+			Width:    sql.NullInt32{Int32: int32(width), Valid: true},
+			Height:   sql.NullInt32{Int32: int32(height), Valid: true},
+			Blurhash: sql.NullString{String: blurhash, Valid: true},
+		})
+	})
+}
+
+// storeThumbnail encodes a thumbnail as JPEG, or as PNG when the original may be transparent.
+func (cfg *apiConfig) storeThumbnail(ctx context.Context, media database.Medium, name string, thumbnail image.Image) (database.AddMediaVariantParams, error) {
+	var buf bytes.Buffer
+	contentType := "image/jpeg"
+	ext := ".jpg"
+	var err error
+	if media.ContentType == "image/png" || media.ContentType == "image/gif" {
+		contentType, ext = "image/png", ".png"
+		err = png.Encode(&buf, thumbnail)
+	} else {
+		err = jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: 85})
+	}
+	if err != nil {
+		return database.AddMediaVariantParams{}, err
+	}
+
+	key := fmt.Sprintf("%s/%s%s", media.ID, name, ext)
+	err = cfg.storage.Put(ctx, key, &buf)
+	if err != nil {
+		return database.AddMediaVariantParams{}, err
+	}
+	return database.AddMediaVariantParams{
+		MediaID:     media.ID,
+		Name:        name,
+		Width:       int32(thumbnail.Bounds().Dx()),
+		Height:      int32(thumbnail.Bounds().Dy()),
+		ContentType: contentType,
+		StorageKey:  key,
+	}, nil
+}
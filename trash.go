@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/docherak/bd-chirpy/internal/auth"
+	"github.com/docherak/bd-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) handlerTrashGet(w http.ResponseWriter, r *http.Request) {
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error getting bearer token", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	page, err := parsePageParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	dbChirps, err := cfg.db.ListDeletedChirps(r.Context(), database.ListDeletedChirpsParams{
+		UserID:          userID,
+		CursorDeletedAt: page.CreatedAt,
+		CursorID:        page.ID,
+		PageSize:        int32(page.Limit + 1),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting trash", err)
+		return
+	}
+
+	apiChirps, err := cfg.databaseChirpsToAPIChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, dbChirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error getting trash", err)
+		return
+	}
+	// The owner still gets to see what they deleted.
+	for i := range apiChirps {
+		apiChirps[i].ContentWarning = dbChirps[i].ContentWarning
+		apiChirps[i].Body = dbChirps[i].Body
+	}
+
+	// Trash is ordered by deletion time, so that's what the cursor points at.
+	chirps := newChirpsPage(apiChirps, page.Limit)
+	if chirps.NextCursor != "" {
+		last := dbChirps[page.Limit-1]
+		chirps.NextCursor = encodeCursor(last.DeletedAt.Time, last.ID)
+	}
+
+	respondWithJSON(w, http.StatusOK, chirps)
+}
+
+func (cfg *apiConfig) handlerChirpsRestore(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't parse UUID", err)
+		return
+	}
+
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Error getting bearer token", err)
+		return
+	}
+
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	dbChirp, err := cfg.db.GetChirpWithDeleted(r.Context(), chirpID)
+	if err != nil || !dbChirp.DeletedAt.Valid || dbChirp.ScrubbedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Chirp not found in trash", err)
+		return
+	}
+
+	if dbChirp.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "Forbidden: You don't own this chirp", err)
+		return
+	}
+
+	var chirp database.Chirp
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		// Rechirps have to be restored first, while they still match the original's deletion time.
+		err := q.RestoreRechirpsOf(r.Context(), chirpID)
+		if err != nil {
+			return err
+		}
+		chirp, err = q.RestoreChirp(r.Context(), chirpID)
+		return err
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't restore chirp", err)
+		return
+	}
+
+	apiChirps, err := cfg.databaseChirpsToAPIChirps(r.Context(), uuid.NullUUID{UUID: userID, Valid: true}, []database.Chirp{chirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't restore chirp", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, apiChirps[0])
+}
+
+// purgeTrash permanently removes chirps that have been in the trash longer than the retention
+// period. Chirps that replies or quotes still point at keep their row as an empty tombstone.
+func (cfg *apiConfig) purgeTrash(ctx context.Context) error {
+	deletedBefore := time.Now().UTC().Add(-cfg.trashRetention)
+	return cfg.withTx(ctx, func(q *database.Queries) error {
+		purged, err := q.PurgeDeletedChirps(ctx, deletedBefore)
+		if err != nil {
+			return err
+		}
+		err = q.DeleteRevisionsOfDeletedChirps(ctx, deletedBefore)
+		if err != nil {
+			return err
+		}
+		err = q.DeleteHashtagsOfDeletedChirps(ctx, deletedBefore)
+		if err != nil {
+			return err
+		}
+		err = q.DeleteMentionsOfDeletedChirps(ctx, deletedBefore)
+		if err != nil {
+			return err
+		}
+		// Detached media is picked up by the orphaned media purge.
+		err = q.DetachMediaOfDeletedChirps(ctx, deletedBefore)
+		if err != nil {
+			return err
+		}
+		scrubbed, err := q.ScrubDeletedChirps(ctx, deletedBefore)
+		if err != nil {
+			return err
+		}
+		if purged > 0 || scrubbed > 0 {
+			log.Printf("Purged %d chirps from the trash, kept %d as tombstones", purged, scrubbed)
+		}
+		return nil
+	})
+}
@@ -1,12 +1,16 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
 	"github.com/docherak/bd-chirpy/internal/auth"
 	"github.com/docherak/bd-chirpy/internal/database"
+	"github.com/docherak/bd-chirpy/internal/entities"
 	"github.com/google/uuid"
 	"net/http"
 	"net/mail"
+	"strings"
 	"time"
 )
 
@@ -15,6 +19,7 @@ type User struct {
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 	Email       string    `json:"email"`
+	Handle      string    `json:"handle"`
 	Password    string    `json:"-"`
 	IsChirpyRed bool      `json:"is_chirpy_red"`
 }
@@ -23,6 +28,7 @@ func (cfg *apiConfig) handlerUsersUpdate(w http.ResponseWriter, r *http.Request)
 	type parameters struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
+		Handle   string `json:"handle"`
 	}
 	type response struct {
 		User
@@ -54,6 +60,11 @@ func (cfg *apiConfig) handlerUsersUpdate(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if params.Handle != "" && !entities.IsValidHandle(params.Handle) {
+		respondWithError(w, http.StatusBadRequest, "Invalid handle", nil)
+		return
+	}
+
 	// TODO: handle password eval
 	hashedPassword, err := auth.HashPassword(params.Password)
 	if err != nil {
@@ -61,24 +72,38 @@ func (cfg *apiConfig) handlerUsersUpdate(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	user, err := cfg.db.UpdateUser(r.Context(), database.UpdateUserParams{
-		ID:             userID,
-		Email:          params.Email,
-		HashedPassword: hashedPassword,
+	// A taken handle must not leave the new email and password behind.
+	var user database.User
+	err = cfg.withTx(r.Context(), func(q *database.Queries) error {
+		var err error
+		user, err = q.UpdateUser(r.Context(), database.UpdateUserParams{
+			ID:             userID,
+			Email:          params.Email,
+			HashedPassword: hashedPassword,
+		})
+		if err != nil {
+			return err
+		}
+		if params.Handle == "" {
+			return nil
+		}
+		user, err = q.SetUserHandle(r.Context(), database.SetUserHandleParams{
+			ID:     userID,
+			Handle: sql.NullString{String: params.Handle, Valid: true},
+		})
+		return err
 	})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "Email or handle is already taken", err)
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
 		return
 	}
 
 	respondWithJSON(w, http.StatusOK, response{
-		User: User{
-			ID:          user.ID,
-			CreatedAt:   user.CreatedAt,
-			UpdatedAt:   user.UpdatedAt,
-			Email:       user.Email,
-			IsChirpyRed: user.IsChirpyRed,
-		},
+		User: databaseUserToAPIUser(user),
 	})
 }
 
@@ -86,6 +111,7 @@ func (cfg *apiConfig) handlerUsersCreate(w http.ResponseWriter, r *http.Request)
 	type parameters struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
+		Handle   string `json:"handle"`
 	}
 
 	decoder := json.NewDecoder(r.Body)
@@ -102,6 +128,11 @@ func (cfg *apiConfig) handlerUsersCreate(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if params.Handle != "" && !entities.IsValidHandle(params.Handle) {
+		respondWithError(w, http.StatusBadRequest, "Invalid handle", nil)
+		return
+	}
+
 	// TODO: handle password eval
 	hashedPassword, err := auth.HashPassword(params.Password)
 	if err != nil {
@@ -114,9 +145,15 @@ func (cfg *apiConfig) handlerUsersCreate(w http.ResponseWriter, r *http.Request)
 	user, err := cfg.db.CreateUser(r.Context(), database.CreateUserParams{
 		Email:          params.Email,
 		HashedPassword: hashedPassword,
+		Handle:         sql.NullString{String: params.Handle, Valid: params.Handle != ""},
 	})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "Email or handle is already taken", err)
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error creating user", err)
+		return
 	}
 
 	apiUser := databaseUserToAPIUser(user)
@@ -130,10 +167,24 @@ func databaseUserToAPIUser(dbUser database.User) User {
 		CreatedAt:   dbUser.CreatedAt,
 		UpdatedAt:   dbUser.UpdatedAt,
 		Email:       dbUser.Email,
+		Handle:      dbUser.Handle.String,
 		IsChirpyRed: dbUser.IsChirpyRed,
 	}
 }
 
+// resolveUserRef accepts either a user ID or a handle, with or without the leading @.
+func (cfg *apiConfig) resolveUserRef(ctx context.Context, ref string) (uuid.UUID, error) {
+	userID, err := uuid.Parse(ref)
+	if err == nil {
+		return userID, nil
+	}
+	user, err := cfg.db.GetUserByHandle(ctx, strings.TrimPrefix(ref, "@"))
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return user.ID, nil
+}
+
 func validateEmail(emailAddress string) error {
 	_, err := mail.ParseAddress(emailAddress)
 	return err
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+
+	"github.com/docherak/bd-chirpy/internal/auth"
+	"github.com/docherak/bd-chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// getViewerID identifies the caller on endpoints that also serve anonymous requests.
+// A missing or invalid token yields an anonymous viewer rather than an error.
+func (cfg *apiConfig) getViewerID(r *http.Request) uuid.NullUUID {
+	bearerToken, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+	userID, err := auth.ValidateJWT(bearerToken, cfg.jwtSecret)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: userID, Valid: true}
+}
+
+// canViewChirp reports whether the viewer may see a chirp that was looked up directly. Drafts
+// and scheduled chirps are only visible to their author, followers-only and mentioned-only
+// chirps to the people they are meant for.
+func (cfg *apiConfig) canViewChirp(ctx context.Context, viewerID uuid.NullUUID, dbChirp database.Chirp) (bool, error) {
+	isAuthor := viewerID.Valid && viewerID.UUID == dbChirp.UserID
+	if dbChirp.Status != chirpStatusPublished || isAuthor {
+		return isAuthor, nil
+	}
+	// Even public chirps go through the database, which also hides suspended and
+	// shadowbanned authors.
+	return cfg.db.IsChirpVisibleTo(ctx, database.IsChirpVisibleToParams{
+		ViewerID: viewerID,
+		ChirpID:  dbChirp.ID,
+	})
+}
+
+// getVisibleChirp looks up a chirp the viewer may see. Chirps they can't see are reported as
+// missing, so callers answer with 404 and don't give away that they exist.
+func (cfg *apiConfig) getVisibleChirp(ctx context.Context, viewerID uuid.NullUUID, chirpID uuid.UUID) (database.Chirp, error) {
+	dbChirp, err := cfg.db.GetChirp(ctx, chirpID)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	visible, err := cfg.canViewChirp(ctx, viewerID, dbChirp)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if !visible {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	return dbChirp, nil
+}
@@ -0,0 +1,22 @@
+package main
+
+import "errors"
+
+// Followers-only chirps are visible to the author's followers, mentioned-only chirps just to
+// the users they mention. Authors and mentioned users can always see a chirp.
+const (
+	chirpVisibilityPublic    = "public"
+	chirpVisibilityFollowers = "followers"
+	chirpVisibilityMentioned = "mentioned"
+)
+
+func parseChirpVisibility(visibility string) (string, error) {
+	switch visibility {
+	case "":
+		return chirpVisibilityPublic, nil
+	case chirpVisibilityPublic, chirpVisibilityFollowers, chirpVisibilityMentioned:
+		return visibility, nil
+	default:
+		return "", errors.New("Unknown visibility")
+	}
+}